@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/schildwaechter/schildcafe.coffeepot/internal/authtoken"
+)
+
+// readerAllowedPaths are the GET endpoints a reader-role token may reach;
+// everything else requires the operator role once auth is enabled.
+var readerAllowedPaths = map[string]bool{
+	"/status":  true,
+	"/history": true,
+	"/metrics": true,
+}
+
+// authMiddleware gates every request except /healthz and /readyz behind an
+// HS256 bearer token: readers may only reach readerAllowedPaths, operators
+// may reach everything. An empty key disables auth entirely, preserving
+// pre-JWT behaviour for deployments that haven't configured one.
+func authMiddleware(next http.Handler, key []byte) http.Handler {
+	if len(key) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := bearerToken(r)
+		if !ok {
+			http.Error(w, "missing bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := authtoken.Parse(token, key)
+		if err != nil {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		if claims.Role == authtoken.RoleOperator {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if claims.Role == authtoken.RoleReader && readerAllowedPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		http.Error(w, "insufficient role", http.StatusForbidden)
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(auth, prefix), true
+}
+
+// jwtKeyFromEnv loads the HS256 signing key from COFFEEPOT_JWT_KEY, falling
+// back to the file named by COFFEEPOT_JWT_KEY_FILE if that's unset. An empty
+// result disables auth, matching newMachineFromEnv's fall back to in-memory
+// defaults when its own env vars are unset.
+func jwtKeyFromEnv(logger *log.Logger) []byte {
+	if key := os.Getenv("COFFEEPOT_JWT_KEY"); key != "" {
+		return []byte(key)
+	}
+
+	path := os.Getenv("COFFEEPOT_JWT_KEY_FILE")
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Fatalf("read COFFEEPOT_JWT_KEY_FILE %q: %v", path, err)
+	}
+	return []byte(strings.TrimSpace(string(data)))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/schildwaechter/schildcafe.coffeepot/internal/authtoken"
+)
+
+func mintToken(t *testing.T, key []byte, role authtoken.Role, ttl time.Duration) string {
+	t.Helper()
+	token, err := authtoken.Sign(key, authtoken.Claims{
+		Subject:   "test",
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+		Role:      role,
+	})
+	if err != nil {
+		t.Fatalf("mint token: %v", err)
+	}
+	return token
+}
+
+func TestAuthMiddlewareMissingTokenReturnsUnauthorized(t *testing.T) {
+	handler := testServerWithAuth(NewMachine(), []byte("secret"))
+
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthMiddlewareExpiredTokenReturnsUnauthorized(t *testing.T) {
+	key := []byte("secret")
+	handler := testServerWithAuth(NewMachine(), key)
+
+	token := mintToken(t, key, authtoken.RoleOperator, -time.Minute)
+
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthMiddlewareReaderCallingStartJobReturnsForbidden(t *testing.T) {
+	key := []byte("secret")
+	handler := testServerWithAuth(NewMachine(), key)
+
+	token := mintToken(t, key, authtoken.RoleReader, time.Hour)
+
+	req := httptest.NewRequest(http.MethodPost, "/start-job", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestAuthMiddlewareValidOperatorTokenSucceeds(t *testing.T) {
+	key := []byte("secret")
+	handler := testServerWithAuth(NewMachine(), key)
+
+	token := mintToken(t, key, authtoken.RoleOperator, time.Hour)
+
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestAuthMiddlewareDisabledPreservesCurrentBehavior(t *testing.T) {
+	handler := testServer(NewMachine())
+
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestAuthMiddlewareReaderCanReachReaderEndpoints(t *testing.T) {
+	key := []byte("secret")
+	handler := testServerWithAuth(NewMachine(), key)
+
+	token := mintToken(t, key, authtoken.RoleReader, time.Hour)
+
+	for _, path := range []string{"/status", "/history", "/metrics"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected status: got %d, want %d", path, rec.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestAuthMiddlewareAllowsHealthzAndReadyzWithoutToken(t *testing.T) {
+	handler := testServerWithAuth(NewMachine(), []byte("secret"))
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code == http.StatusUnauthorized {
+			t.Fatalf("%s: expected no auth required, got %d", path, rec.Code)
+		}
+	}
+}
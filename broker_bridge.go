@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/schildwaechter/schildcafe.coffeepot/internal/broker"
+)
+
+const (
+	ordersSubject       = "coffee.orders.*"
+	completedSubjectFmt = "coffee.completed.%s"
+	retrievedSubjectFmt = "coffee.retrieved.%s"
+	ordersBucket        = "orders"
+
+	// brokerStreamName is the JetStream stream every coffeepot instance
+	// connected to the same NATS deployment shares, which is what lets them
+	// observe each other's order publishes, completions, and retrievals.
+	brokerStreamName = "COFFEE_ORDERS"
+	// brokerSubjectWildcard covers every subject this bridge publishes or
+	// subscribes to, so one stream backs all of them.
+	brokerSubjectWildcard = "coffee.>"
+)
+
+// orderMessage is the payload expected on the orders subject.
+type orderMessage struct {
+	JobID    string  `json:"jobId"`
+	Product  Product `json:"product"`
+	Priority int     `json:"priority,omitempty"`
+}
+
+// startBrokerBridge wires machine up to a NATS JetStream order broker in the
+// background so the coffeepot can accept orders published on coffee.orders.*
+// in addition to the HTTP API. natsURL is a NATS server (or cluster) address;
+// every coffeepot instance pointed at the same one shares brokerStreamName,
+// so orders, completions, and retrievals published by one instance are seen
+// by all of them.
+func startBrokerBridge(ctx context.Context, natsURL string, machine *Machine, logger *log.Logger) {
+	b, err := broker.Dial(natsURL, brokerStreamName, []string{brokerSubjectWildcard})
+	if err != nil {
+		logger.Printf("broker bridge: dial %s: %v", natsURL, err)
+		return
+	}
+	logger.Printf("broker bridge: consuming %s on %s (stream %s)", ordersSubject, natsURL, brokerStreamName)
+
+	go func() {
+		defer b.Close()
+		if err := runBrokerBridge(ctx, b, machine, logger); err != nil {
+			logger.Printf("broker bridge stopped: %v", err)
+		}
+	}()
+}
+
+// runBrokerBridge consumes orders off b until ctx is cancelled, starting a
+// job on machine for each one and publishing completion/retrieval events to
+// b for any consumer of b watching them.
+func runBrokerBridge(ctx context.Context, b broker.Broker, machine *Machine, logger *log.Logger) error {
+	consumer, err := b.PullSubscribe(ordersSubject)
+	if err != nil {
+		return fmt.Errorf("subscribe to %s: %w", ordersSubject, err)
+	}
+	defer consumer.Close()
+
+	machine.OnEvent(func(eventType string, job Job) {
+		var subject string
+		switch eventType {
+		case "ready":
+			subject = fmt.Sprintf(completedSubjectFmt, job.JobID)
+		case "retrieved":
+			subject = fmt.Sprintf(retrievedSubjectFmt, job.JobID)
+		default:
+			return
+		}
+
+		data, err := json.Marshal(job)
+		if err != nil {
+			logger.Printf("broker: marshal job %s: %v", job.JobID, err)
+			return
+		}
+		if err := b.Publish(subject, data); err != nil {
+			logger.Printf("broker: publish %s: %v", subject, err)
+			return
+		}
+		_ = b.KVPut(ordersBucket, job.JobID, data)
+	})
+
+	for {
+		delivery, err := consumer.Fetch(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return nil
+			}
+			return fmt.Errorf("fetch order: %w", err)
+		}
+
+		var order orderMessage
+		if err := json.Unmarshal(delivery.Data, &order); err != nil {
+			logger.Printf("broker: invalid order on %s: %v", delivery.Subject, err)
+			delivery.Ack() // malformed payloads will never decode; don't redeliver forever
+			continue
+		}
+
+		job, err := machine.StartJob(order.Product, order.JobID, order.Priority, "")
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrUnsupportedProduct), errors.Is(err, ErrJobIDExists):
+				logger.Printf("broker: rejecting order %s: %v", order.JobID, err)
+				delivery.Ack()
+			default:
+				logger.Printf("broker: requeueing order %s: %v", order.JobID, err)
+				delivery.Nack()
+			}
+			continue
+		}
+
+		data, _ := json.Marshal(job)
+		_ = b.KVPut(ordersBucket, job.JobID, data)
+		delivery.Ack()
+	}
+}
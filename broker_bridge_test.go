@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/schildwaechter/schildcafe.coffeepot/internal/broker"
+)
+
+func TestRunBrokerBridgeStartsJobFromOrder(t *testing.T) {
+	b := broker.NewMemory()
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 10 * time.Millisecond }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_ = runBrokerBridge(ctx, b, m, log.New(io.Discard, "", 0))
+	}()
+
+	order, err := json.Marshal(orderMessage{JobID: "job-1", Product: ProductCoffee})
+	if err != nil {
+		t.Fatalf("marshal order: %v", err)
+	}
+	if err := b.Publish("coffee.orders.job-1", order); err != nil {
+		t.Fatalf("publish order: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		if history := m.History(); len(history) == 1 && history[0].JobID == "job-1" {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for order to start a job")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	deadline = time.After(time.Second)
+	for {
+		if _, ok, _ := b.KVGet(ordersBucket, "job-1"); ok {
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for job to appear in KV bucket")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
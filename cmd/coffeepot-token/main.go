@@ -0,0 +1,54 @@
+// Command coffeepot-token mints HS256 JWTs for the coffeepot's admin API,
+// for minting local test tokens without standing up a full auth service.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/schildwaechter/schildcafe.coffeepot/internal/authtoken"
+)
+
+func main() {
+	sub := flag.String("sub", "local", "subject claim")
+	role := flag.String("role", "operator", "role claim: operator or reader")
+	ttl := flag.Duration("ttl", time.Hour, "time until the token expires")
+	key := flag.String("key", os.Getenv("COFFEEPOT_JWT_KEY"), "HS256 signing key")
+	keyFile := flag.String("key-file", os.Getenv("COFFEEPOT_JWT_KEY_FILE"), "path to a file containing the HS256 signing key")
+	flag.Parse()
+
+	signingKey := []byte(*key)
+	if len(signingKey) == 0 && *keyFile != "" {
+		data, err := os.ReadFile(*keyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "coffeepot-token: read key file: %v\n", err)
+			os.Exit(1)
+		}
+		signingKey = []byte(strings.TrimSpace(string(data)))
+	}
+	if len(signingKey) == 0 {
+		fmt.Fprintln(os.Stderr, "coffeepot-token: no signing key; set -key, -key-file, COFFEEPOT_JWT_KEY, or COFFEEPOT_JWT_KEY_FILE")
+		os.Exit(1)
+	}
+
+	r := authtoken.Role(*role)
+	if r != authtoken.RoleOperator && r != authtoken.RoleReader {
+		fmt.Fprintf(os.Stderr, "coffeepot-token: unknown role %q, want operator or reader\n", *role)
+		os.Exit(1)
+	}
+
+	token, err := authtoken.Sign(signingKey, authtoken.Claims{
+		Subject:   *sub,
+		ExpiresAt: time.Now().Add(*ttl).Unix(),
+		Role:      r,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "coffeepot-token: sign token: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(token)
+}
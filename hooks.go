@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// hookMaxAttempts bounds how many times a single callback is attempted
+	// (the first try plus up to three retries) before the delivery is
+	// recorded as a terminal failure.
+	hookMaxAttempts = 4
+	// hookOutboxSize bounds the dispatcher's backlog so a slow or failing
+	// callback endpoint can never block the brewing path.
+	hookOutboxSize = 256
+	// hookHistorySize bounds how many past delivery attempts GET /hooks/status
+	// reports.
+	hookHistorySize = 50
+	hookTimeout     = 5 * time.Second
+	// hookWorkers is how many goroutines concurrently drain the outbox, so a
+	// slow or retrying callback for one job doesn't delay delivery for
+	// unrelated jobs queued behind it.
+	hookWorkers = 8
+)
+
+// hookPayload is the JSON body POSTed to a job's CallbackURL once it's ready.
+type hookPayload struct {
+	JobID    string    `json:"jobID"`
+	Product  Product   `json:"product"`
+	JobReady time.Time `json:"jobReady"`
+	Status   string    `json:"status"`
+}
+
+// HookDelivery records the outcome of a single webhook delivery attempt, as
+// returned by GET /hooks/status.
+type HookDelivery struct {
+	JobID      string    `json:"jobID"`
+	URL        string    `json:"url"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Outcome    string    `json:"outcome"`
+	At         time.Time `json:"at"`
+}
+
+// hookClient dispatches webhook callbacks for completed jobs off a bounded
+// outbox, retrying with exponential backoff, so a slow or unreachable
+// callback endpoint can never stall the brewing path. A pool of hookWorkers
+// goroutines drains the outbox concurrently, so one job's slow or retrying
+// callback doesn't delay delivery for unrelated jobs queued behind it.
+type hookClient struct {
+	httpClient *http.Client
+	backoffFn  func(attempt int) time.Duration
+	metrics    Metrics
+	outbox     chan Job
+
+	mu      sync.Mutex
+	history []HookDelivery
+}
+
+func newHookClient(metrics Metrics) *hookClient {
+	if metrics == nil {
+		metrics = noopMetrics{}
+	}
+
+	h := &hookClient{
+		httpClient: &http.Client{Timeout: hookTimeout},
+		backoffFn:  defaultHookBackoff,
+		metrics:    metrics,
+		outbox:     make(chan Job, hookOutboxSize),
+	}
+	for i := 0; i < hookWorkers; i++ {
+		go h.run()
+	}
+	return h
+}
+
+// defaultHookBackoff returns the delay before retry attempt n (1-indexed):
+// 1s, 4s, 16s.
+func defaultHookBackoff(attempt int) time.Duration {
+	switch attempt {
+	case 1:
+		return time.Second
+	case 2:
+		return 4 * time.Second
+	default:
+		return 16 * time.Second
+	}
+}
+
+// dispatch enqueues job for callback delivery. It is a no-op if the job has
+// no CallbackURL. Delivery is best-effort: if the outbox is full, the
+// callback is dropped rather than blocking the caller.
+func (h *hookClient) dispatch(job Job) {
+	if job.CallbackURL == "" {
+		return
+	}
+	select {
+	case h.outbox <- job:
+	default:
+	}
+}
+
+// run is one of hookWorkers identical consumers draining the shared outbox.
+func (h *hookClient) run() {
+	for job := range h.outbox {
+		h.deliver(job)
+	}
+}
+
+// deliver POSTs job's payload to its CallbackURL, retrying with backoff on
+// failure until hookMaxAttempts attempts have been made. A terminal failure is recorded but
+// never propagated back to the brewing path.
+func (h *hookClient) deliver(job Job) {
+	data, err := json.Marshal(hookPayload{
+		JobID:    job.JobID,
+		Product:  job.Product,
+		JobReady: job.JobReady,
+		Status:   "ready",
+	})
+	if err != nil {
+		h.record(HookDelivery{JobID: job.JobID, URL: job.CallbackURL, Outcome: "marshal_error", Error: err.Error(), At: time.Now()})
+		return
+	}
+
+	for attempt := 1; attempt <= hookMaxAttempts; attempt++ {
+		status, err := h.post(job.CallbackURL, data)
+		delivery := HookDelivery{JobID: job.JobID, URL: job.CallbackURL, Attempt: attempt, StatusCode: status, At: time.Now()}
+
+		if err == nil && status >= 200 && status < 300 {
+			delivery.Outcome = "delivered"
+			h.record(delivery)
+			return
+		}
+
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+
+		if attempt == hookMaxAttempts {
+			delivery.Outcome = "failed"
+			h.record(delivery)
+			return
+		}
+
+		delivery.Outcome = "retrying"
+		h.record(delivery)
+		time.Sleep(h.backoffFn(attempt))
+	}
+}
+
+func (h *hookClient) post(url string, data []byte) (int, error) {
+	resp, err := h.httpClient.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func (h *hookClient) record(delivery HookDelivery) {
+	h.metrics.HookAttempt(delivery.Outcome)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.history = append(h.history, delivery)
+	if len(h.history) > hookHistorySize {
+		h.history = h.history[len(h.history)-hookHistorySize:]
+	}
+}
+
+// Recent returns the most recent delivery attempts, oldest first.
+func (h *hookClient) Recent() []HookDelivery {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]HookDelivery, len(h.history))
+	copy(out, h.history)
+	return out
+}
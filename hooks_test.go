@@ -0,0 +1,161 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHookClientDeliversOnSuccess(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	h := newHookClient(noopMetrics{})
+	h.dispatch(Job{JobID: "job-1", Product: ProductCoffee, CallbackURL: srv.URL})
+
+	deadline := time.After(time.Second)
+	for atomic.LoadInt32(&calls) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for callback delivery")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	deadline = time.After(time.Second)
+	for len(h.Recent()) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for delivery to be recorded")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	recent := h.Recent()
+	if recent[len(recent)-1].Outcome != "delivered" {
+		t.Fatalf("expected delivered outcome, got %+v", recent[len(recent)-1])
+	}
+}
+
+func TestHookClientRetriesOn5xxThenSucceeds(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	h := newHookClient(noopMetrics{})
+	h.backoffFn = func(int) time.Duration { return time.Millisecond }
+	h.dispatch(Job{JobID: "job-1", Product: ProductCoffee, CallbackURL: srv.URL})
+
+	deadline := time.After(time.Second)
+	for atomic.LoadInt32(&calls) < 2 {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for retry")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	deadline = time.After(time.Second)
+	for {
+		recent := h.Recent()
+		if len(recent) > 0 && recent[len(recent)-1].Outcome == "delivered" {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("timed out waiting for eventual delivery, got %+v", recent)
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+// TestHookClientSlowCallbackDoesNotDelayUnrelatedDelivery guards against a
+// single shared consumer goroutine: a slow endpoint for one job must not
+// hold up delivery to a different, fast-responding job queued behind it.
+func TestHookClientSlowCallbackDoesNotDelayUnrelatedDelivery(t *testing.T) {
+	unblock := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slow.Close()
+
+	var fastCalls int32
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fastCalls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fast.Close()
+
+	h := newHookClient(noopMetrics{})
+	defer close(unblock)
+
+	h.dispatch(Job{JobID: "slow-job", Product: ProductCoffee, CallbackURL: slow.URL})
+	h.dispatch(Job{JobID: "fast-job", Product: ProductCoffee, CallbackURL: fast.URL})
+
+	deadline := time.After(time.Second)
+	for atomic.LoadInt32(&fastCalls) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for fast job's callback, a slow callback blocked the outbox")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestHookClientRecordsTerminalFailureWithoutFailingJob(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	h := newHookClient(noopMetrics{})
+	h.backoffFn = func(int) time.Duration { return time.Millisecond }
+	h.dispatch(Job{JobID: "job-1", Product: ProductCoffee, CallbackURL: srv.URL})
+
+	deadline := time.After(time.Second)
+	for {
+		recent := h.Recent()
+		if len(recent) > 0 && recent[len(recent)-1].Outcome == "failed" {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("timed out waiting for terminal failure, got %+v", recent)
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	// The job that owns this callback is never mutated by hookClient, so a
+	// failing endpoint cannot affect the brewing/retrieval path.
+	m := NewMachineWithMetrics(1, NewMemoryStore(), noopMetrics{})
+	m.brewTimeFn = func() time.Duration { return time.Millisecond }
+	job, err := m.StartJob(ProductCoffee, "", 0, srv.URL)
+	if err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+
+	deadline = time.After(time.Second)
+	for {
+		if _, err := m.RetrieveJob(job.JobID); err == nil {
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatal("expected job to become retrievable despite a failing callback")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
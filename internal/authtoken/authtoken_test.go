@@ -0,0 +1,53 @@
+package authtoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignAndParseRoundTrip(t *testing.T) {
+	key := []byte("test-key")
+	claims := Claims{Subject: "alice", ExpiresAt: time.Now().Add(time.Hour).Unix(), Role: RoleOperator}
+
+	token, err := Sign(key, claims)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	got, err := Parse(token, key)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got != claims {
+		t.Fatalf("expected claims %+v, got %+v", claims, got)
+	}
+}
+
+func TestParseRejectsExpiredToken(t *testing.T) {
+	key := []byte("test-key")
+	token, err := Sign(key, Claims{Subject: "alice", ExpiresAt: time.Now().Add(-time.Minute).Unix(), Role: RoleOperator})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	if _, err := Parse(token, key); err != ErrExpired {
+		t.Fatalf("expected ErrExpired, got %v", err)
+	}
+}
+
+func TestParseRejectsWrongKey(t *testing.T) {
+	token, err := Sign([]byte("correct-key"), Claims{Subject: "alice", ExpiresAt: time.Now().Add(time.Hour).Unix(), Role: RoleOperator})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	if _, err := Parse(token, []byte("wrong-key")); err != ErrInvalidSignature {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func TestParseRejectsMalformedToken(t *testing.T) {
+	if _, err := Parse("not-a-jwt", []byte("key")); err != ErrMalformed {
+		t.Fatalf("expected ErrMalformed, got %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+// Package broker provides a small subject-based pub/sub abstraction that
+// mirrors the subset of NATS JetStream semantics the coffeepot needs: wildcard
+// subjects, a durable pull consumer with ack/nack, and a key/value bucket for
+// sharing pending-order state across instances. Broker is an interface with
+// two implementations: NATS, backed by a real JetStream stream and KV bucket,
+// and Memory, which keeps everything in process.
+package broker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrClosed is returned by operations on a closed broker.
+var ErrClosed = errors.New("broker: closed")
+
+// Message is a single published record.
+type Message struct {
+	Subject string
+	Data    []byte
+}
+
+// Delivery is a Message handed to a pull consumer, which must Ack or Nack it.
+// Nacking re-enqueues the message for redelivery. Memory does not redeliver
+// a Delivery that is neither acked nor nacked; the message is lost.
+type Delivery struct {
+	Message
+	Ack  func()
+	Nack func()
+}
+
+// Consumer pulls deliveries for the subjects it was created with.
+type Consumer interface {
+	Fetch(ctx context.Context) (Delivery, error)
+	Close() error
+}
+
+// Broker is the minimal publish/subscribe/KV surface the coffeepot relies on.
+type Broker interface {
+	Publish(subject string, data []byte) error
+	PullSubscribe(subject string) (Consumer, error)
+	KVPut(bucket, key string, data []byte) error
+	KVGet(bucket, key string) (data []byte, ok bool, err error)
+	Close() error
+}
+
+// Memory is an in-process Broker: messages never leave the running process,
+// but the subject matching, durable redelivery-on-nack, and KV semantics
+// match what a JetStream-backed Broker would provide. Published messages
+// that no consumer is currently waiting for sit in a backlog until a
+// matching Fetch claims them, just like a JetStream stream would retain
+// them for a not-yet-connected durable consumer.
+type Memory struct {
+	mu      sync.Mutex
+	closed  bool
+	backlog []Message
+	waiting map[string][]chan Message // subject pattern -> pull consumers queued on it
+	kv      map[string]map[string][]byte
+}
+
+// NewMemory constructs an empty in-process broker.
+func NewMemory() *Memory {
+	return &Memory{
+		waiting: make(map[string][]chan Message),
+		kv:      make(map[string]map[string][]byte),
+	}
+}
+
+// Publish delivers data to subject. A pull consumer already blocked in Fetch
+// on a matching pattern receives it directly; otherwise it joins the backlog
+// for the next matching Fetch to claim.
+func (b *Memory) Publish(subject string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+
+	msg := Message{Subject: subject, Data: data}
+	for pattern, queues := range b.waiting {
+		if !subjectMatches(pattern, subject) || len(queues) == 0 {
+			continue
+		}
+		ch := queues[0]
+		b.waiting[pattern] = queues[1:]
+		ch <- msg
+		return nil
+	}
+
+	b.backlog = append(b.backlog, msg)
+	return nil
+}
+
+// PullSubscribe returns a durable pull consumer for subject, which may
+// contain '*' (single token) or '>' (remaining tokens) wildcards.
+func (b *Memory) PullSubscribe(subject string) (Consumer, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	return &memoryConsumer{broker: b, subject: subject}, nil
+}
+
+// claimBacklog returns and removes the oldest backlogged message matching
+// pattern, if any.
+func (b *Memory) claimBacklog(pattern string) (Message, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, msg := range b.backlog {
+		if subjectMatches(pattern, msg.Subject) {
+			b.backlog = append(b.backlog[:i], b.backlog[i+1:]...)
+			return msg, true
+		}
+	}
+	return Message{}, false
+}
+
+func (b *Memory) enqueue(pattern string, ch chan Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.waiting[pattern] = append(b.waiting[pattern], ch)
+}
+
+func (b *Memory) dequeue(pattern string, ch chan Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	queues := b.waiting[pattern]
+	for i, c := range queues {
+		if c == ch {
+			b.waiting[pattern] = append(queues[:i], queues[i+1:]...)
+			return
+		}
+	}
+}
+
+// KVPut stores data under bucket/key, creating bucket if necessary.
+func (b *Memory) KVPut(bucket, key string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+
+	if b.kv[bucket] == nil {
+		b.kv[bucket] = make(map[string][]byte)
+	}
+	b.kv[bucket][key] = data
+
+	return nil
+}
+
+// KVGet retrieves the value stored under bucket/key, if any.
+func (b *Memory) KVGet(bucket, key string) ([]byte, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, false, ErrClosed
+	}
+
+	data, ok := b.kv[bucket][key]
+	return data, ok, nil
+}
+
+// Close marks the broker closed; further publishes and subscribes fail.
+func (b *Memory) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return nil
+}
+
+type memoryConsumer struct {
+	broker  *Memory
+	subject string
+}
+
+// Fetch blocks until a message arrives or ctx is done; closing the consumer
+// or the broker does not wake it. Nacking the returned Delivery re-queues it
+// for the next Fetch.
+func (c *memoryConsumer) Fetch(ctx context.Context) (Delivery, error) {
+	if msg, ok := c.broker.claimBacklog(c.subject); ok {
+		return c.broker.delivery(msg), nil
+	}
+
+	ch := make(chan Message, 1)
+	c.broker.enqueue(c.subject, ch)
+
+	select {
+	case msg := <-ch:
+		return c.broker.delivery(msg), nil
+	case <-ctx.Done():
+		c.broker.dequeue(c.subject, ch)
+		return Delivery{}, ctx.Err()
+	}
+}
+
+func (c *memoryConsumer) Close() error { return nil }
+
+func (b *Memory) delivery(msg Message) Delivery {
+	return Delivery{
+		Message: msg,
+		Ack:     func() {},
+		Nack:    func() { _ = b.Publish(msg.Subject, msg.Data) },
+	}
+}
+
+// subjectMatches reports whether subject satisfies the NATS-style pattern,
+// where '*' matches exactly one dot-separated token and a trailing '>'
+// matches one or more remaining tokens.
+func subjectMatches(pattern, subject string) bool {
+	pTokens := strings.Split(pattern, ".")
+	sTokens := strings.Split(subject, ".")
+
+	for i, pt := range pTokens {
+		if pt == ">" {
+			return i < len(sTokens)
+		}
+		if i >= len(sTokens) {
+			return false
+		}
+		if pt != "*" && pt != sTokens[i] {
+			return false
+		}
+	}
+
+	return len(pTokens) == len(sTokens)
+}
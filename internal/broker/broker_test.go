@@ -0,0 +1,108 @@
+package broker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPublishSubscribeWildcard(t *testing.T) {
+	b := NewMemory()
+	consumer, err := b.PullSubscribe("coffee.orders.*")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer consumer.Close()
+
+	done := make(chan Delivery, 1)
+	go func() {
+		d, err := consumer.Fetch(context.Background())
+		if err != nil {
+			t.Errorf("fetch: %v", err)
+			return
+		}
+		done <- d
+	}()
+
+	time.Sleep(10 * time.Millisecond) // let the consumer start waiting
+	if err := b.Publish("coffee.orders.job-1", []byte("hello")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	select {
+	case d := <-done:
+		if string(d.Data) != "hello" {
+			t.Fatalf("unexpected payload: %q", d.Data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+}
+
+func TestNackRedelivers(t *testing.T) {
+	b := NewMemory()
+	consumer, err := b.PullSubscribe("coffee.orders.*")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer consumer.Close()
+
+	if err := b.Publish("coffee.orders.job-1", []byte("payload")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	first, err := consumer.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("first fetch: %v", err)
+	}
+	first.Nack()
+
+	second, err := consumer.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("second fetch after nack: %v", err)
+	}
+	if string(second.Data) != "payload" {
+		t.Fatalf("unexpected redelivered payload: %q", second.Data)
+	}
+}
+
+func TestKVPutGet(t *testing.T) {
+	b := NewMemory()
+
+	if _, ok, _ := b.KVGet("orders", "job-1"); ok {
+		t.Fatal("expected no value before put")
+	}
+
+	if err := b.KVPut("orders", "job-1", []byte("data")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	data, ok, err := b.KVGet("orders", "job-1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !ok || string(data) != "data" {
+		t.Fatalf("unexpected value: %q ok=%v", data, ok)
+	}
+}
+
+func TestSubjectMatches(t *testing.T) {
+	cases := []struct {
+		pattern, subject string
+		want             bool
+	}{
+		{"coffee.orders.*", "coffee.orders.job-1", true},
+		{"coffee.orders.*", "coffee.orders.job-1.extra", false},
+		{"coffee.>", "coffee.orders.job-1", true},
+		{"coffee.orders.*", "coffee.completed.job-1", false},
+	}
+
+	for _, c := range cases {
+		if got := subjectMatches(c.pattern, c.subject); got != c.want {
+			t.Errorf("subjectMatches(%q, %q) = %v, want %v", c.pattern, c.subject, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// natsOpTimeout bounds how long a single control-plane NATS/JetStream
+// operation (connect, create stream/consumer/KV bucket, publish, KV get/put)
+// is allowed to take.
+const natsOpTimeout = 5 * time.Second
+
+// natsFetchMaxWait bounds a single pull-consumer Fetch request, so Fetch can
+// check ctx for cancellation between requests instead of blocking on one
+// NATS round trip for the whole call.
+const natsFetchMaxWait = time.Second
+
+// NATS is a Broker backed by a real NATS JetStream stream and KV bucket, so
+// published orders, completion events, and pending-order KV state are
+// actually shared by every coffeepot instance connected to the same NATS
+// deployment, not just within one process.
+type NATS struct {
+	nc     *nats.Conn
+	js     jetstream.JetStream
+	stream jetstream.Stream
+
+	mu  sync.Mutex
+	kvs map[string]jetstream.KeyValue
+}
+
+// Dial connects to the NATS server at url and ensures the JetStream stream
+// covering subjects exists, creating it if necessary. streamName and
+// subjects are shared across every coffeepot instance pointed at the same
+// NATS deployment, which is what lets them observe each other's publishes.
+func Dial(url, streamName string, subjects []string) (*NATS, error) {
+	nc, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("broker: connect to %s: %w", url, err)
+	}
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("broker: jetstream context: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), natsOpTimeout)
+	defer cancel()
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     streamName,
+		Subjects: subjects,
+	})
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("broker: create stream %s: %w", streamName, err)
+	}
+
+	return &NATS{nc: nc, js: js, stream: stream, kvs: make(map[string]jetstream.KeyValue)}, nil
+}
+
+// Publish persists data to subject on the underlying JetStream stream.
+func (n *NATS) Publish(subject string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), natsOpTimeout)
+	defer cancel()
+	_, err := n.js.Publish(ctx, subject, data)
+	return err
+}
+
+// PullSubscribe returns a durable JetStream pull consumer for subject, which
+// may contain '*' (single token) or '>' (remaining tokens) wildcards. The
+// consumer's durable name is derived from subject, so every coffeepot
+// instance subscribing to the same subject shares one durable consumer and
+// its delivery/ack state.
+func (n *NATS) PullSubscribe(subject string) (Consumer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), natsOpTimeout)
+	defer cancel()
+
+	consumer, err := n.stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:       durableConsumerName(subject),
+		FilterSubject: subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("broker: create consumer for %s: %w", subject, err)
+	}
+	return &natsConsumer{consumer: consumer}, nil
+}
+
+// durableConsumerName turns a subject (which may contain '.', '*', '>') into
+// a valid durable consumer name.
+func durableConsumerName(subject string) string {
+	replacer := strings.NewReplacer(".", "_", "*", "STAR", ">", "REST")
+	return "coffeepot-" + replacer.Replace(subject)
+}
+
+// kvStore returns the JetStream KV bucket named bucket, creating it on first use.
+func (n *NATS) kvStore(bucket string) (jetstream.KeyValue, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if kv, ok := n.kvs[bucket]; ok {
+		return kv, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), natsOpTimeout)
+	defer cancel()
+	kv, err := n.js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{Bucket: bucket})
+	if err != nil {
+		return nil, fmt.Errorf("broker: create KV bucket %s: %w", bucket, err)
+	}
+	n.kvs[bucket] = kv
+	return kv, nil
+}
+
+// KVPut stores data under bucket/key in the shared JetStream KV bucket.
+func (n *NATS) KVPut(bucket, key string, data []byte) error {
+	kv, err := n.kvStore(bucket)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), natsOpTimeout)
+	defer cancel()
+	_, err = kv.Put(ctx, key, data)
+	return err
+}
+
+// KVGet retrieves the value stored under bucket/key from the shared
+// JetStream KV bucket, if any.
+func (n *NATS) KVGet(bucket, key string) ([]byte, bool, error) {
+	kv, err := n.kvStore(bucket)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), natsOpTimeout)
+	defer cancel()
+	entry, err := kv.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return entry.Value(), true, nil
+}
+
+// Close closes the underlying NATS connection.
+func (n *NATS) Close() error {
+	n.nc.Close()
+	return nil
+}
+
+type natsConsumer struct {
+	consumer jetstream.Consumer
+}
+
+// Fetch blocks until a message arrives or ctx is done, polling the
+// JetStream pull consumer in natsFetchMaxWait increments so ctx cancellation
+// is never delayed by more than one increment.
+func (c *natsConsumer) Fetch(ctx context.Context) (Delivery, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return Delivery{}, ctx.Err()
+		default:
+		}
+
+		batch, err := c.consumer.Fetch(1, jetstream.FetchMaxWait(natsFetchMaxWait))
+		if err != nil {
+			return Delivery{}, fmt.Errorf("broker: fetch: %w", err)
+		}
+
+		select {
+		case msg, ok := <-batch.Messages():
+			if ok {
+				return Delivery{
+					Message: Message{Subject: msg.Subject(), Data: msg.Data()},
+					Ack:     func() { _ = msg.Ack() },
+					Nack:    func() { _ = msg.Nak() },
+				}, nil
+			}
+			if err := batch.Error(); err != nil && !errors.Is(err, nats.ErrTimeout) {
+				return Delivery{}, fmt.Errorf("broker: fetch: %w", err)
+			}
+			// Nothing arrived within natsFetchMaxWait; loop and try again.
+		case <-ctx.Done():
+			return Delivery{}, ctx.Err()
+		}
+	}
+}
+
+func (c *natsConsumer) Close() error { return nil }
@@ -0,0 +1,109 @@
+package broker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+// startTestNATSServer boots an embedded, JetStream-enabled NATS server for
+// the duration of the test, so these tests exercise the real NATS/JetStream
+// client without depending on an external process.
+func startTestNATSServer(t *testing.T) string {
+	t.Helper()
+
+	opts := &server.Options{Port: -1, JetStream: true, StoreDir: t.TempDir()}
+	ns, err := server.NewServer(opts)
+	if err != nil {
+		t.Fatalf("start embedded NATS server: %v", err)
+	}
+
+	go ns.Start()
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("embedded NATS server never became ready")
+	}
+	t.Cleanup(ns.Shutdown)
+
+	return ns.ClientURL()
+}
+
+func TestNATSPublishSubscribeWildcard(t *testing.T) {
+	url := startTestNATSServer(t)
+
+	b, err := Dial(url, "TEST_COFFEE_ORDERS", []string{"coffee.>"})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer b.Close()
+
+	consumer, err := b.PullSubscribe("coffee.orders.*")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer consumer.Close()
+
+	if err := b.Publish("coffee.orders.job-1", []byte("hello")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	delivery, err := consumer.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if delivery.Subject != "coffee.orders.job-1" || string(delivery.Data) != "hello" {
+		t.Fatalf("unexpected delivery: %+v", delivery)
+	}
+	delivery.Ack()
+}
+
+func TestNATSFetchRespectsContextCancellation(t *testing.T) {
+	url := startTestNATSServer(t)
+
+	b, err := Dial(url, "TEST_COFFEE_ORDERS_EMPTY", []string{"coffee.>"})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer b.Close()
+
+	consumer, err := b.PullSubscribe("coffee.orders.*")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer consumer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := consumer.Fetch(ctx); err == nil {
+		t.Fatal("expected fetch on an empty subject to time out via ctx")
+	}
+}
+
+func TestNATSKVRoundTrips(t *testing.T) {
+	url := startTestNATSServer(t)
+
+	b, err := Dial(url, "TEST_COFFEE_ORDERS_KV", []string{"coffee.>"})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer b.Close()
+
+	if _, ok, err := b.KVGet("orders", "job-1"); err != nil || ok {
+		t.Fatalf("expected missing key, got ok=%v err=%v", ok, err)
+	}
+
+	if err := b.KVPut("orders", "job-1", []byte("payload")); err != nil {
+		t.Fatalf("kv put: %v", err)
+	}
+
+	data, ok, err := b.KVGet("orders", "job-1")
+	if err != nil {
+		t.Fatalf("kv get: %v", err)
+	}
+	if !ok || string(data) != "payload" {
+		t.Fatalf("expected payload, got data=%q ok=%v", data, ok)
+	}
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	crand "crypto/rand"
 	"errors"
 	"fmt"
+	"io"
 	mrand "math/rand"
+	"sort"
 	"sync"
 	"time"
 )
@@ -35,7 +38,8 @@ var supportedProducts = map[Product]struct{}{
 	ProductHotWater:          {},
 }
 
-// MachineState tracks the lifecycle of a job on the machine.
+// MachineState summarizes the machine as a whole, kept for callers that only
+// care about the coarse picture (e.g. the legacy metrics gauge).
 type MachineState int
 
 const (
@@ -44,18 +48,56 @@ const (
 	StateBlocked
 )
 
+// JobState tracks the lifecycle of an individual job as it moves through the
+// queue and brew heads.
+type JobState string
+
+const (
+	JobPending   JobState = "Pending"
+	JobBrewing   JobState = "Brewing"
+	JobReady     JobState = "Ready"
+	JobRetrieved JobState = "Retrieved"
+	JobExpired   JobState = "Expired"
+)
+
 const (
 	minBrewSeconds = 20
 	maxBrewSeconds = 55
+
+	// defaultHeads is the number of concurrent brew heads a plain NewMachine
+	// exposes, matching the historical single-slot behaviour.
+	defaultHeads = 1
+
+	// snapshotInterval is how often a persistent Machine compacts its WAL
+	// into a fresh snapshot.
+	snapshotInterval = time.Minute
+
+	// defaultRetrievalTTL is how long a ready job may sit unretrieved before
+	// housekeeping expires it and frees its brew head.
+	defaultRetrievalTTL = 5 * time.Minute
+
+	// defaultHistoryRetention is how long a finished job's full record is
+	// kept in memory before housekeeping prunes it down to a compact
+	// historySummary, which History() keeps reporting indefinitely.
+	defaultHistoryRetention = 24 * time.Hour
+
+	// housekeepingInterval is how often the housekeeping loop sweeps for
+	// expired and prunable jobs.
+	housekeepingInterval = 30 * time.Second
 )
 
 // Job holds all information for a single brew request.
 type Job struct {
 	JobID        string     `json:"jobId"`
 	Product      Product    `json:"product"`
-	JobStarted   time.Time  `json:"jobStarted"`
+	State        JobState   `json:"state"`
+	Priority     int        `json:"priority,omitempty"`
+	JobStarted   time.Time  `json:"jobStarted,omitempty"`
 	JobReady     time.Time  `json:"jobReady"`
 	JobRetrieved *time.Time `json:"jobRetrieved,omitempty"`
+	CallbackURL  string     `json:"callbackUrl,omitempty"`
+
+	seq uint64 // insertion order, used to break priority ties FIFO
 }
 
 var (
@@ -65,28 +107,356 @@ var (
 	ErrJobNotReady         = errors.New("job not ready")
 	ErrJobAlreadyRetrieved = errors.New("job already retrieved")
 	ErrJobIDExists         = errors.New("job ID already exists")
+	ErrJobNotPending       = errors.New("job is not pending")
+	ErrJobExpired          = errors.New("job expired before retrieval")
 )
 
-// Machine manages a single in-memory coffee machine instance.
+// brewSlot is one concurrent brew head. An empty jobID means the head is
+// idle and available to pull the next pending job.
+type brewSlot struct {
+	jobID string
+}
+
+// QueueStatus is the structured payload served from /status.
+type QueueStatus struct {
+	Heads        int `json:"heads"`
+	QueueDepth   int `json:"queueDepth"`
+	ActiveBrews  int `json:"activeBrews"`
+	BlockedSlots int `json:"blockedSlots"`
+}
+
+// historySummary is the compact record housekeeping keeps for a job once its
+// full Job entry is pruned from m.jobs, so History() can keep reporting the
+// job's outcome long after its detailed record is gone.
+type historySummary struct {
+	JobID        string
+	Product      Product
+	State        JobState
+	JobReady     time.Time
+	JobRetrieved *time.Time
+}
+
+func (s historySummary) toJob() Job {
+	return Job{
+		JobID:        s.JobID,
+		Product:      s.Product,
+		State:        s.State,
+		JobReady:     s.JobReady,
+		JobRetrieved: s.JobRetrieved,
+	}
+}
+
+// Machine manages a coffee machine instance with one or more concurrent
+// brew heads and a priority-ordered pending queue.
 type Machine struct {
-	mu         sync.Mutex
-	state      MachineState
-	jobs       map[string]*Job
-	history    []string
-	currentJob string
-	rand       *mrand.Rand
-	brewTimeFn func() time.Duration
+	mu            sync.Mutex
+	jobs          map[string]*Job
+	pending       []*Job
+	slots         []brewSlot
+	history       []string
+	prunedHistory map[string]historySummary
+	nextSeq       uint64
+	rand          *mrand.Rand
+	brewTimeFn    func() time.Duration
+	nowFn         func() time.Time
+	store         JobStore
+	metrics       Metrics
+	waiters       map[string][]chan struct{}
+	hooks         *hookClient
+	progress      map[string]*jobProgress
+
+	retrievalTTL     time.Duration
+	historyRetention time.Duration
+	queueCap         int // 0 means unbounded
+
+	obsMu       sync.Mutex
+	observers   []func(eventType string, job Job)
+	subscribers map[chan machineEvent]struct{}
+	events      chan machineEvent
 }
 
-// NewMachine constructs an idle machine ready to accept jobs.
+// machineEvent is a fire-and-forget notification of a job state transition,
+// delivered to observers registered via OnEvent.
+type machineEvent struct {
+	Type string
+	Job  Job
+}
+
+// eventsBuffer bounds how many transitions can be queued for observers
+// before newer ones are dropped rather than blocking the brewing path.
+const eventsBuffer = 256
+
+// NewMachine constructs an idle, single-head machine ready to accept jobs.
+// Its state is kept in memory only; use NewMachineWithStore for a machine
+// that survives restarts.
 func NewMachine() *Machine {
+	return NewMachineWithConfig(defaultHeads)
+}
+
+// NewMachineWithConfig constructs an idle machine with the given number of
+// concurrent brew heads. heads <= 0 is treated as defaultHeads.
+func NewMachineWithConfig(heads int) *Machine {
+	return newMachine(heads, NewMemoryStore(), newMetrics())
+}
+
+// NewMachineWithStore constructs a machine backed by store, replaying any
+// previously recorded jobs before accepting new ones.
+func NewMachineWithStore(heads int, store JobStore) *Machine {
+	return newMachine(heads, store, newMetrics())
+}
+
+// NewMachineWithMetrics constructs a machine backed by store that reports
+// through metrics instead of the default in-process collector. Tests that
+// don't care about instrumentation can pass noopMetrics{}.
+func NewMachineWithMetrics(heads int, store JobStore, metrics Metrics) *Machine {
+	return newMachine(heads, store, metrics)
+}
+
+func newMachine(heads int, store JobStore, metrics Metrics) *Machine {
+	if heads <= 0 {
+		heads = defaultHeads
+	}
+	if metrics == nil {
+		metrics = noopMetrics{}
+	}
+
 	r := mrand.New(mrand.NewSource(time.Now().UnixNano()))
-	return &Machine{
-		state:      StateAvailable,
-		jobs:       make(map[string]*Job),
-		history:    make([]string, 0),
-		rand:       r,
-		brewTimeFn: defaultBrewTime(r),
+	m := &Machine{
+		jobs:          make(map[string]*Job),
+		slots:         make([]brewSlot, heads),
+		history:       make([]string, 0),
+		prunedHistory: make(map[string]historySummary),
+		rand:          r,
+		brewTimeFn:    defaultBrewTime(r),
+		nowFn:         time.Now,
+		store:         store,
+		metrics:       metrics,
+		waiters:       make(map[string][]chan struct{}),
+		events:        make(chan machineEvent, eventsBuffer),
+		hooks:         newHookClient(metrics),
+		progress:      make(map[string]*jobProgress),
+
+		retrievalTTL:     defaultRetrievalTTL,
+		historyRetention: defaultHistoryRetention,
+	}
+	m.subscribers = make(map[chan machineEvent]struct{})
+
+	m.recover()
+	go m.snapshotLoop()
+	go m.dispatchEvents()
+	go m.housekeepingLoop()
+
+	return m
+}
+
+// OnEvent registers fn to be called, from a dedicated dispatcher goroutine,
+// whenever a job is started, begins brewing, becomes ready, is retrieved, or
+// expires unretrieved; cancellations and Reset emit no event. Delivery is
+// best-effort: if the internal event buffer is full, the event is dropped
+// rather than blocking the brewing path.
+func (m *Machine) OnEvent(fn func(eventType string, job Job)) {
+	m.obsMu.Lock()
+	defer m.obsMu.Unlock()
+	m.observers = append(m.observers, fn)
+}
+
+// Subscribe registers a channel that receives every subsequent event that
+// OnEvent observers receive, for as long as the returned unsubscribe func hasn't been
+// called. Delivery is best-effort: a slow reader that lets the channel fill
+// up misses events rather than stalling the brewing path. Callers must call
+// unsubscribe exactly once, typically via defer, to release the channel.
+func (m *Machine) Subscribe() (ch <-chan machineEvent, unsubscribe func()) {
+	sub := make(chan machineEvent, eventsBuffer)
+
+	m.obsMu.Lock()
+	m.subscribers[sub] = struct{}{}
+	m.obsMu.Unlock()
+
+	return sub, func() {
+		m.obsMu.Lock()
+		defer m.obsMu.Unlock()
+		if _, ok := m.subscribers[sub]; ok {
+			delete(m.subscribers, sub)
+			close(sub)
+		}
+	}
+}
+
+func (m *Machine) emit(eventType string, job Job) {
+	select {
+	case m.events <- machineEvent{Type: eventType, Job: job}:
+	default:
+	}
+}
+
+func (m *Machine) dispatchEvents() {
+	for ev := range m.events {
+		m.obsMu.Lock()
+		var observers []func(string, Job)
+		observers = append(observers, m.observers...)
+		for sub := range m.subscribers {
+			select {
+			case sub <- ev:
+			default:
+			}
+		}
+		m.obsMu.Unlock()
+
+		for _, fn := range observers {
+			fn(ev.Type, ev.Job)
+		}
+	}
+}
+
+// recover replays the store's latest snapshot and WAL tail into the machine,
+// re-arming brew completion for jobs still in flight. It is best-effort: a
+// store error leaves the machine starting clean rather than failing to boot.
+func (m *Machine) recover() {
+	jobs, history, err := m.store.Recover()
+	if err != nil || len(jobs) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.history = history
+	slotsUsed := 0
+
+	for seq, id := range history {
+		job, ok := jobs[id]
+		if !ok {
+			continue
+		}
+		job.seq = uint64(seq)
+		stored := job
+		m.jobs[id] = &stored
+		if uint64(seq) >= m.nextSeq {
+			m.nextSeq = uint64(seq) + 1
+		}
+
+		switch stored.State {
+		case JobPending:
+			m.insertPendingLocked(m.jobs[id])
+		case JobBrewing:
+			if slotsUsed >= len(m.slots) {
+				m.jobs[id].State = JobPending
+				m.insertPendingLocked(m.jobs[id])
+				continue
+			}
+			slot := slotsUsed
+			slotsUsed++
+			m.slots[slot].jobID = id
+			if remaining := time.Until(stored.JobReady); remaining > 0 {
+				go m.awaitCompletion(slot, id, remaining)
+			} else {
+				m.jobs[id].State = JobReady
+			}
+		case JobReady:
+			if slotsUsed < len(m.slots) {
+				m.slots[slotsUsed].jobID = id
+				slotsUsed++
+			}
+		}
+	}
+}
+
+// snapshotLoop periodically compacts the store's WAL into a fresh snapshot.
+// For the default MemoryStore this is a harmless no-op.
+func (m *Machine) snapshotLoop() {
+	ticker := time.NewTicker(snapshotInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		m.snapshotOnce()
+	}
+}
+
+// snapshotOnce copies the current jobs/history and hands them to
+// store.Snapshot, which persists them and truncates the WAL. The copy and
+// the WAL truncation happen under the same m.mu critical section as every
+// store.Append: releasing the lock in between would let a job transition
+// that occurred after the copy get appended to the WAL and then wiped out
+// by the truncate, vanishing without ever appearing in either the snapshot
+// or the (now-truncated) WAL.
+func (m *Machine) snapshotOnce() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make(map[string]Job, len(m.jobs))
+	for id, job := range m.jobs {
+		jobs[id] = *job
+	}
+	history := append([]string(nil), m.history...)
+
+	_ = m.store.Snapshot(jobs, history)
+}
+
+// housekeepingLoop periodically expires ready jobs nobody retrieved in time
+// and prunes old finished jobs from memory, covering the "someone forgot
+// their coffee" failure mode the state machine can't detect on its own.
+func (m *Machine) housekeepingLoop() {
+	ticker := time.NewTicker(housekeepingInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		m.runHousekeeping()
+	}
+}
+
+func (m *Machine) runHousekeeping() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	for i := range m.slots {
+		id := m.slots[i].jobID
+		if id == "" {
+			continue
+		}
+		job, ok := m.jobs[id]
+		if !ok || job.State != JobReady {
+			continue
+		}
+		if now.Sub(job.JobReady) <= m.retrievalTTL {
+			continue
+		}
+
+		job.State = JobExpired
+		m.slots[i].jobID = ""
+		if tracker, ok := m.progress[id]; ok {
+			tracker.finish(JobExpired)
+		}
+
+		_ = m.store.Append(StoreEvent{Type: eventExpired, Job: *job, At: now})
+		m.metrics.JobFailed("retrieval_ttl_expired")
+		m.notifyWaitersLocked(id)
+		m.emit(string(eventExpired), *job)
+	}
+
+	m.dispatchLocked()
+
+	for id, job := range m.jobs {
+		if job.State != JobRetrieved && job.State != JobExpired {
+			continue
+		}
+
+		finishedAt := job.JobReady
+		if job.JobRetrieved != nil {
+			finishedAt = *job.JobRetrieved
+		}
+		if now.Sub(finishedAt) > m.historyRetention {
+			m.prunedHistory[id] = historySummary{
+				JobID:        job.JobID,
+				Product:      job.Product,
+				State:        job.State,
+				JobReady:     job.JobReady,
+				JobRetrieved: job.JobRetrieved,
+			}
+			delete(m.jobs, id)
+			delete(m.progress, id)
+		}
 	}
 }
 
@@ -97,32 +467,126 @@ func defaultBrewTime(r *mrand.Rand) func() time.Duration {
 	}
 }
 
-// Ready reports whether the machine can accept a new job.
+// Ready reports whether the machine has at least one idle brew head.
 func (m *Machine) Ready() bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return m.state == StateAvailable
+	return m.hasIdleSlotLocked()
+}
+
+func (m *Machine) hasIdleSlotLocked() bool {
+	for _, s := range m.slots {
+		if s.jobID == "" {
+			return true
+		}
+	}
+	return false
 }
 
-// Status returns the current machine state.
+// Status reports the coarse machine-wide state, kept for backward
+// compatibility with callers built against the single-slot model.
 func (m *Machine) Status() MachineState {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return m.state
+	return m.summaryLocked()
 }
 
-// StartJob queues a brew request if the machine is idle.
-func (m *Machine) StartJob(product Product, jobID string) (Job, error) {
-	if !product.valid() {
-		return Job{}, ErrUnsupportedProduct
+func (m *Machine) summaryLocked() MachineState {
+	active, blocked := 0, 0
+	for _, s := range m.slots {
+		if s.jobID == "" {
+			continue
+		}
+		if job, ok := m.jobs[s.jobID]; ok && job.State == JobReady {
+			blocked++
+		} else {
+			active++
+		}
+	}
+
+	switch {
+	case blocked == len(m.slots):
+		return StateBlocked
+	case active+blocked > 0:
+		return StateBrewing
+	default:
+		return StateAvailable
 	}
+}
 
+// StatusReport returns the structured queue/brew-head picture served from
+// /status.
+func (m *Machine) StatusReport() QueueStatus {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if m.state != StateAvailable {
-		return Job{}, ErrMachineBusy
+	report := QueueStatus{
+		Heads:      len(m.slots),
+		QueueDepth: len(m.pending),
+	}
+	for _, s := range m.slots {
+		if s.jobID == "" {
+			continue
+		}
+		if job, ok := m.jobs[s.jobID]; ok && job.State == JobReady {
+			report.BlockedSlots++
+		} else {
+			report.ActiveBrews++
+		}
+	}
+
+	return report
+}
+
+// SetQueueCap bounds the pending queue to at most n waiting jobs; StartJob
+// rejects further requests with ErrMachineBusy once the bound is reached.
+// n <= 0 means unbounded, the default.
+func (m *Machine) SetQueueCap(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queueCap = n
+}
+
+// WriteMetrics refreshes the machine's point-in-time gauges and renders
+// every counter, gauge, and histogram in Prometheus text exposition format.
+func (m *Machine) WriteMetrics(w io.Writer) error {
+	report := m.StatusReport()
+	m.metrics.SetQueueDepth(report.QueueDepth)
+	m.metrics.SetActiveBrews(report.ActiveBrews)
+	m.metrics.SetBlockedSlots(report.BlockedSlots)
+	for _, state := range []JobState{JobPending, JobBrewing, JobReady, JobRetrieved, JobExpired} {
+		m.metrics.SetJobsByState(state, m.jobStateCount(state))
 	}
+	m.metrics.SetMachineStatus(m.Status())
+
+	return m.metrics.Render(w)
+}
+
+func (m *Machine) jobStateCount(state JobState) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, job := range m.jobs {
+		if job.State == state {
+			count++
+		}
+	}
+	return count
+}
+
+// StartJob enqueues a brew request. Higher-priority jobs jump ahead of
+// lower-priority ones in the pending queue; jobs with equal priority are
+// served FIFO. The job is dispatched onto a free brew head immediately if
+// one is available.
+func (m *Machine) StartJob(product Product, jobID string, priority int, callbackURL string) (Job, error) {
+	if !product.valid() {
+		m.metrics.JobFailed("unsupported_product")
+		return Job{}, ErrUnsupportedProduct
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
 	if jobID == "" {
 		var err error
@@ -133,31 +597,176 @@ func (m *Machine) StartJob(product Product, jobID string) (Job, error) {
 	}
 
 	if _, exists := m.jobs[jobID]; exists {
+		m.metrics.JobFailed("job_id_exists")
 		return Job{}, ErrJobIDExists
 	}
 
-	started := time.Now()
-	brewDuration := m.brewTimeFn()
-	readyAt := started.Add(brewDuration)
+	if m.queueCap > 0 && len(m.pending) >= m.queueCap {
+		m.metrics.JobFailed("queue_full")
+		return Job{}, ErrMachineBusy
+	}
 
 	job := &Job{
-		JobID:      jobID,
-		Product:    product,
-		JobStarted: started,
-		JobReady:   readyAt,
+		JobID:       jobID,
+		Product:     product,
+		State:       JobPending,
+		Priority:    priority,
+		JobReady:    m.estimateReadyLocked(),
+		CallbackURL: callbackURL,
+		seq:         m.nextSeq,
 	}
+	m.nextSeq++
 
 	m.jobs[jobID] = job
 	m.history = append(m.history, jobID)
-	m.currentJob = jobID
-	m.state = StateBrewing
+	m.insertPendingLocked(job)
 
-	go m.awaitCompletion(jobID, brewDuration)
+	_ = m.store.Append(StoreEvent{Type: eventStarted, Job: *job, At: time.Now()})
+	m.metrics.JobStarted(product)
+	m.emit(string(eventStarted), *job)
+
+	m.dispatchLocked()
 
 	return *job, nil
 }
 
-// RetrieveJob returns a brewed job if it is ready and not yet retrieved.
+// WaitReady blocks until jobID becomes ready, ctx is done, or the job
+// reaches a terminal or already-ready state, whichever happens first.
+func (m *Machine) WaitReady(ctx context.Context, jobID string) (Job, error) {
+	m.mu.Lock()
+	job, exists := m.jobs[jobID]
+	if !exists {
+		m.mu.Unlock()
+		return Job{}, ErrJobNotFound
+	}
+	if job.State != JobPending && job.State != JobBrewing {
+		defer m.mu.Unlock()
+		return *job, nil
+	}
+
+	ch := make(chan struct{})
+	m.waiters[jobID] = append(m.waiters[jobID], ch)
+	m.mu.Unlock()
+
+	select {
+	case <-ch:
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if job, ok := m.jobs[jobID]; ok {
+			return *job, nil
+		}
+		return Job{}, ErrJobNotFound
+	case <-ctx.Done():
+		return Job{}, ctx.Err()
+	}
+}
+
+// notifyWaitersLocked wakes every goroutine blocked in WaitReady for jobID.
+func (m *Machine) notifyWaitersLocked(jobID string) {
+	for _, ch := range m.waiters[jobID] {
+		close(ch)
+	}
+	delete(m.waiters, jobID)
+}
+
+// insertPendingLocked inserts job into the pending queue, keeping it sorted
+// by descending priority with FIFO order (ascending seq) among ties.
+func (m *Machine) insertPendingLocked(job *Job) {
+	idx := sort.Search(len(m.pending), func(i int) bool {
+		return m.pending[i].Priority < job.Priority
+	})
+	m.pending = append(m.pending, nil)
+	copy(m.pending[idx+1:], m.pending[idx:])
+	m.pending[idx] = job
+}
+
+// estimateReadyLocked gives a rough JobReady estimate for a newly enqueued
+// job, based on the average brew time for every job already ahead of it.
+// dispatchLocked overwrites this with the real value once the job starts
+// brewing.
+func (m *Machine) estimateReadyLocked() time.Time {
+	avg := time.Duration((minBrewSeconds+maxBrewSeconds)/2) * time.Second
+	ahead := len(m.pending)
+	idle := 0
+	for _, s := range m.slots {
+		if s.jobID == "" {
+			idle++
+		}
+	}
+	if ahead < idle {
+		return time.Now().Add(avg)
+	}
+	rounds := (ahead-idle)/len(m.slots) + 1
+	return time.Now().Add(time.Duration(rounds) * avg)
+}
+
+// recalcPendingEstimatesLocked refreshes the estimated JobReady of every
+// still-pending job, reflecting their new position after a cancellation
+// changed the queue or freed a brew head.
+func (m *Machine) recalcPendingEstimatesLocked() {
+	avg := time.Duration((minBrewSeconds+maxBrewSeconds)/2) * time.Second
+	idle := 0
+	for _, s := range m.slots {
+		if s.jobID == "" {
+			idle++
+		}
+	}
+
+	for i, job := range m.pending {
+		if i < idle {
+			job.JobReady = time.Now().Add(avg)
+			continue
+		}
+		rounds := (i-idle)/len(m.slots) + 1
+		job.JobReady = time.Now().Add(time.Duration(rounds) * avg)
+	}
+}
+
+// NextAvailableAt estimates when a newly submitted job would next have room
+// in the queue, based on the current queue tail. Used to derive a
+// Retry-After value when StartJob rejects a request for a full queue.
+func (m *Machine) NextAvailableAt() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.pending) == 0 {
+		return time.Now()
+	}
+	return m.pending[len(m.pending)-1].JobReady
+}
+
+// dispatchLocked pulls pending jobs onto any idle brew heads.
+func (m *Machine) dispatchLocked() {
+	for i := range m.slots {
+		if m.slots[i].jobID != "" {
+			continue
+		}
+		if len(m.pending) == 0 {
+			return
+		}
+
+		job := m.pending[0]
+		m.pending = m.pending[1:]
+
+		job.State = JobBrewing
+		job.JobStarted = time.Now()
+		duration := m.brewTimeFn()
+		job.JobReady = job.JobStarted.Add(duration)
+
+		m.slots[i].jobID = job.JobID
+
+		phases := phasesForProduct(job.Product)
+		m.progress[job.JobID] = newJobProgress(job.JobID, phases[0])
+
+		_ = m.store.Append(StoreEvent{Type: eventBrewing, Job: *job, At: time.Now()})
+		m.emit(string(eventBrewing), *job)
+
+		go m.awaitCompletion(i, job.JobID, duration)
+	}
+}
+
+// RetrieveJob returns a brewed job if it is ready and not yet retrieved,
+// freeing its brew head for the next pending job.
 func (m *Machine) RetrieveJob(jobID string) (Job, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -171,21 +780,125 @@ func (m *Machine) RetrieveJob(jobID string) (Job, error) {
 		return Job{}, ErrJobAlreadyRetrieved
 	}
 
-	if time.Now().Before(job.JobReady) {
+	if job.State == JobExpired {
+		return Job{}, ErrJobExpired
+	}
+
+	if job.State != JobReady {
 		return Job{}, ErrJobNotReady
 	}
 
 	now := time.Now()
 	job.JobRetrieved = &now
+	job.State = JobRetrieved
+	if tracker, ok := m.progress[jobID]; ok {
+		tracker.setState(JobRetrieved)
+	}
 
-	if m.currentJob == jobID {
-		m.currentJob = ""
-		m.state = StateAvailable
+	for i := range m.slots {
+		if m.slots[i].jobID == jobID {
+			m.slots[i].jobID = ""
+			break
+		}
 	}
 
+	_ = m.store.Append(StoreEvent{Type: eventRetrieved, Job: *job, At: now})
+	m.metrics.JobRetrieved(job.Product)
+	m.metrics.ObserveRetrievalLatency(now.Sub(job.JobReady))
+	m.emit(string(eventRetrieved), *job)
+
+	m.dispatchLocked()
+
 	return *job, nil
 }
 
+// CancelJob removes a pending job from the queue, or aborts it if it is
+// already brewing, discarding any in-progress brew. Jobs that are ready,
+// retrieved, or already expired cannot be cancelled this way. Cancelling
+// recomputes the estimated JobReady of every job still queued behind it.
+func (m *Machine) CancelJob(jobID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, exists := m.jobs[jobID]
+	if !exists {
+		return ErrJobNotFound
+	}
+
+	switch job.State {
+	case JobPending:
+		for i, pj := range m.pending {
+			if pj.JobID == jobID {
+				m.pending = append(m.pending[:i], m.pending[i+1:]...)
+				break
+			}
+		}
+	case JobBrewing:
+		for i := range m.slots {
+			if m.slots[i].jobID == jobID {
+				m.slots[i].jobID = ""
+				break
+			}
+		}
+	default:
+		return ErrJobNotPending
+	}
+
+	job.State = JobExpired
+	m.notifyWaitersLocked(jobID)
+	if tracker, ok := m.progress[jobID]; ok {
+		tracker.finish(JobExpired)
+	}
+
+	m.dispatchLocked()
+	m.recalcPendingEstimatesLocked()
+
+	_ = m.store.Append(StoreEvent{Type: eventCancelled, Job: *job, At: time.Now()})
+	m.metrics.JobFailed("cancelled")
+
+	return nil
+}
+
+// Reset cancels every pending or brewing job and clears the queue, for the
+// operator-only /admin/reset endpoint to recover a stuck machine without
+// restarting the process.
+func (m *Machine) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range m.jobs {
+		if job.State != JobPending && job.State != JobBrewing {
+			continue
+		}
+
+		job.State = JobExpired
+		m.notifyWaitersLocked(job.JobID)
+		if tracker, ok := m.progress[job.JobID]; ok {
+			tracker.finish(JobExpired)
+		}
+
+		_ = m.store.Append(StoreEvent{Type: eventCancelled, Job: *job, At: time.Now()})
+		m.metrics.JobFailed("reset")
+	}
+
+	m.pending = nil
+	for i := range m.slots {
+		m.slots[i].jobID = ""
+	}
+}
+
+// Queue returns a snapshot of the pending queue in dispatch order.
+func (m *Machine) Queue() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	queue := make([]Job, 0, len(m.pending))
+	for _, job := range m.pending {
+		queue = append(queue, *job)
+	}
+	return queue
+}
+
 // History returns a snapshot of all jobs in submission order.
 func (m *Machine) History() []Job {
 	m.mu.Lock()
@@ -195,21 +908,115 @@ func (m *Machine) History() []Job {
 	for _, id := range m.history {
 		if job, ok := m.jobs[id]; ok {
 			jobs = append(jobs, *job)
+		} else if summary, ok := m.prunedHistory[id]; ok {
+			jobs = append(jobs, summary.toJob())
 		}
 	}
 
 	return jobs
 }
 
-func (m *Machine) awaitCompletion(jobID string, duration time.Duration) {
+// awaitCompletion walks jobID through its brew phases, broadcasting each
+// transition to any subscribers on GET /jobs/{jobID}/events, before marking
+// it ready once duration has elapsed.
+func (m *Machine) awaitCompletion(slot int, jobID string, duration time.Duration) {
+	m.mu.Lock()
+	job, ok := m.jobs[jobID]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	phases := phasesForProduct(job.Product)
+	tracker := m.progress[jobID]
+	m.mu.Unlock()
+
+	// Broadcast phase transitions on a best-effort basis in the background
+	// so the overall wait below still takes exactly duration, regardless of
+	// how many phases it's sliced into.
+	done := make(chan struct{})
+	if tracker != nil && len(phases) > 1 {
+		sliceDuration := duration / time.Duration(len(phases))
+		if sliceDuration > 0 {
+			go func() {
+				ticker := time.NewTicker(sliceDuration)
+				defer ticker.Stop()
+				for i := 1; i < len(phases); i++ {
+					select {
+					case <-ticker.C:
+						tracker.update(phases[i], i*100/len(phases))
+					case <-done:
+						return
+					}
+				}
+			}()
+		}
+	}
+
 	time.Sleep(duration)
+	close(done)
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if job, ok := m.jobs[jobID]; ok && job.JobRetrieved == nil {
-		m.state = StateBlocked
+	job, ok = m.jobs[jobID]
+	if !ok {
+		return
+	}
+
+	if job.State != JobBrewing {
+		// Cancelled mid-brew: nothing left to mark ready, but subscribers
+		// still need a terminal event to close their connection.
+		if tracker != nil {
+			tracker.finish(job.State)
+		}
+		return
+	}
+
+	job.State = JobReady
+	_ = m.store.Append(StoreEvent{Type: eventReady, Job: *job, At: time.Now()})
+	m.metrics.ObserveBrewDuration(job.Product, duration)
+	m.notifyWaitersLocked(jobID)
+	m.emit(string(eventReady), *job)
+	m.hooks.dispatch(*job)
+	if tracker != nil {
+		tracker.finish(JobReady)
+	}
+}
+
+// SubscribeJobEvents streams phase/progress updates for jobID. A subscriber
+// attaching mid-brew immediately receives the current phase as its first
+// event; one already terminal (ready, retrieved, expired, or never started
+// brewing in this process) immediately receives a single "done" event.
+func (m *Machine) SubscribeJobEvents(jobID string) (<-chan Event, func(), error) {
+	m.mu.Lock()
+	job, exists := m.jobs[jobID]
+	if !exists {
+		m.mu.Unlock()
+		return nil, nil, ErrJobNotFound
+	}
+	tracker, hasTracker := m.progress[jobID]
+	state := job.State
+	m.mu.Unlock()
+
+	if hasTracker {
+		ch, unsubscribe := tracker.subscribe()
+		return ch, unsubscribe, nil
 	}
+
+	progress := 0
+	if state == JobReady || state == JobRetrieved {
+		progress = 100
+	}
+	ch := make(chan Event, 1)
+	ch <- Event{Type: "done", JobID: jobID, Phase: "ready", Progress: progress, State: state}
+	close(ch)
+	return ch, func() {}, nil
+}
+
+// RecentHookDeliveries returns the most recent webhook delivery attempts,
+// newest last, for inspection via GET /hooks/status.
+func (m *Machine) RecentHookDeliveries() []HookDelivery {
+	return m.hooks.Recent()
 }
 
 func (p Product) valid() bool {
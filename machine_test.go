@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -9,7 +11,7 @@ func TestMachineHappyPath(t *testing.T) {
 	m := NewMachine()
 	m.brewTimeFn = func() time.Duration { return 10 * time.Millisecond }
 
-	job, err := m.StartJob(ProductCoffee, "")
+	job, err := m.StartJob(ProductCoffee, "", 0, "")
 	if err != nil {
 		t.Fatalf("start job: %v", err)
 	}
@@ -48,7 +50,7 @@ func TestMachineHappyPath(t *testing.T) {
 
 func TestUnsupportedProductRejected(t *testing.T) {
 	m := NewMachine()
-	if _, err := m.StartJob(Product("INVALID"), ""); err == nil {
+	if _, err := m.StartJob(Product("INVALID"), "", 0, ""); err == nil {
 		t.Fatalf("expected unsupported product to fail")
 	}
 }
@@ -57,7 +59,7 @@ func TestRetrieveBeforeReadyFails(t *testing.T) {
 	m := NewMachine()
 	m.brewTimeFn = func() time.Duration { return 50 * time.Millisecond }
 
-	job, err := m.StartJob(ProductEspresso, "")
+	job, err := m.StartJob(ProductEspresso, "", 0, "")
 	if err != nil {
 		t.Fatalf("start job: %v", err)
 	}
@@ -75,11 +77,297 @@ func TestRetrieveBeforeReadyFails(t *testing.T) {
 
 func TestDuplicateJobID(t *testing.T) {
 	m := NewMachine()
-	if _, err := m.StartJob(ProductKakao, "job-1"); err != nil {
+	if _, err := m.StartJob(ProductKakao, "job-1", 0, ""); err != nil {
 		t.Fatalf("first job: %v", err)
 	}
 
-	if _, err := m.StartJob(ProductKakao, "job-1"); err == nil {
+	if _, err := m.StartJob(ProductKakao, "job-1", 0, ""); err == nil {
 		t.Fatalf("expected duplicate job ID to fail")
 	}
 }
+
+func TestStartJobQueuesWhileBrewing(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 30 * time.Millisecond }
+
+	first, err := m.StartJob(ProductCoffee, "first", 0, "")
+	if err != nil {
+		t.Fatalf("start first job: %v", err)
+	}
+	if first.State != JobBrewing {
+		t.Fatalf("expected first job to be brewing, got %v", first.State)
+	}
+
+	second, err := m.StartJob(ProductCoffee, "second", 0, "")
+	if err != nil {
+		t.Fatalf("start second job: %v", err)
+	}
+	if second.State != JobPending {
+		t.Fatalf("expected second job to be queued, got %v", second.State)
+	}
+
+	queue := m.Queue()
+	if len(queue) != 1 || queue[0].JobID != "second" {
+		t.Fatalf("expected second job in queue, got %+v", queue)
+	}
+}
+
+func TestPriorityJumpsQueue(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 50 * time.Millisecond }
+
+	if _, err := m.StartJob(ProductCoffee, "brewing", 0, ""); err != nil {
+		t.Fatalf("start brewing job: %v", err)
+	}
+	if _, err := m.StartJob(ProductCoffee, "low", 0, ""); err != nil {
+		t.Fatalf("start low priority job: %v", err)
+	}
+	if _, err := m.StartJob(ProductCoffee, "high", 5, ""); err != nil {
+		t.Fatalf("start high priority job: %v", err)
+	}
+
+	queue := m.Queue()
+	if len(queue) != 2 || queue[0].JobID != "high" || queue[1].JobID != "low" {
+		t.Fatalf("expected high priority job first, got %+v", queue)
+	}
+}
+
+func TestConcurrentBrewHeads(t *testing.T) {
+	m := NewMachineWithConfig(2)
+	m.brewTimeFn = func() time.Duration { return 20 * time.Millisecond }
+
+	first, err := m.StartJob(ProductCoffee, "first", 0, "")
+	if err != nil {
+		t.Fatalf("start first job: %v", err)
+	}
+	second, err := m.StartJob(ProductCoffee, "second", 0, "")
+	if err != nil {
+		t.Fatalf("start second job: %v", err)
+	}
+
+	if first.State != JobBrewing || second.State != JobBrewing {
+		t.Fatalf("expected both jobs to brew concurrently, got %v and %v", first.State, second.State)
+	}
+	if len(m.Queue()) != 0 {
+		t.Fatalf("expected empty queue with two free heads")
+	}
+}
+
+func TestCancelPendingJob(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 50 * time.Millisecond }
+
+	if _, err := m.StartJob(ProductCoffee, "brewing", 0, ""); err != nil {
+		t.Fatalf("start brewing job: %v", err)
+	}
+	if _, err := m.StartJob(ProductCoffee, "queued", 0, ""); err != nil {
+		t.Fatalf("start queued job: %v", err)
+	}
+
+	if err := m.CancelJob("queued"); err != nil {
+		t.Fatalf("cancel queued job: %v", err)
+	}
+	if len(m.Queue()) != 0 {
+		t.Fatalf("expected queue to be empty after cancellation")
+	}
+
+	if err := m.CancelJob("brewing"); err != nil {
+		t.Fatalf("expected cancelling a brewing job to abort it: %v", err)
+	}
+	if m.Ready() != true {
+		t.Fatalf("expected aborting a brewing job to free its brew head")
+	}
+
+	if err := m.CancelJob("missing"); err == nil {
+		t.Fatalf("expected cancelling an unknown job to fail")
+	}
+}
+
+func TestCancelBrewingJobDispatchesNextQueuedJob(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 50 * time.Millisecond }
+
+	if _, err := m.StartJob(ProductCoffee, "brewing", 0, ""); err != nil {
+		t.Fatalf("start brewing job: %v", err)
+	}
+	if _, err := m.StartJob(ProductCoffee, "queued", 0, ""); err != nil {
+		t.Fatalf("start queued job: %v", err)
+	}
+
+	if err := m.CancelJob("brewing"); err != nil {
+		t.Fatalf("abort brewing job: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		m.mu.Lock()
+		state := m.jobs["queued"].State
+		m.mu.Unlock()
+		if state == JobBrewing || state == JobReady {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("expected queued job to be dispatched onto the freed brew head")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestStartJobRejectsWhenQueueFull(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 50 * time.Millisecond }
+	m.SetQueueCap(1)
+
+	if _, err := m.StartJob(ProductCoffee, "brewing", 0, ""); err != nil {
+		t.Fatalf("start brewing job: %v", err)
+	}
+	if _, err := m.StartJob(ProductCoffee, "queued", 0, ""); err != nil {
+		t.Fatalf("start queued job: %v", err)
+	}
+
+	if _, err := m.StartJob(ProductCoffee, "overflow", 0, ""); !errors.Is(err, ErrMachineBusy) {
+		t.Fatalf("expected ErrMachineBusy once the queue is full, got %v", err)
+	}
+}
+
+func TestHousekeepingExpiresUnretrievedReadyJob(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return time.Millisecond }
+	m.retrievalTTL = time.Millisecond
+
+	job, err := m.StartJob(ProductCoffee, "", 0, "")
+	if err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		m.mu.Lock()
+		state := m.jobs[job.JobID].State
+		m.mu.Unlock()
+		if state == JobReady {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for job to become ready")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	m.runHousekeeping()
+
+	if _, err := m.RetrieveJob(job.JobID); !errors.Is(err, ErrJobExpired) {
+		t.Fatalf("expected ErrJobExpired, got %v", err)
+	}
+	if !m.Ready() {
+		t.Fatalf("expected expiry to free the brew head")
+	}
+}
+
+func TestHousekeepingPrunesOldFinishedJobs(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return time.Millisecond }
+	m.historyRetention = time.Millisecond
+
+	job, err := m.StartJob(ProductCoffee, "", 0, "")
+	if err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		if _, err := m.RetrieveJob(job.JobID); err == nil {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for job to become retrievable")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	m.runHousekeeping()
+
+	m.mu.Lock()
+	_, exists := m.jobs[job.JobID]
+	m.mu.Unlock()
+	if exists {
+		t.Fatalf("expected retrieved job to be pruned from memory")
+	}
+
+	history := m.History()
+	if len(history) != 1 || history[0].JobID != job.JobID {
+		t.Fatalf("expected pruned job to still appear in history, got %v", history)
+	}
+	if history[0].State != JobRetrieved {
+		t.Fatalf("expected pruned history entry to keep its final state, got %v", history[0].State)
+	}
+}
+
+func TestWaitReadyReturnsOnceJobIsReady(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 10 * time.Millisecond }
+
+	job, err := m.StartJob(ProductCoffee, "", 0, "")
+	if err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ready, err := m.WaitReady(ctx, job.JobID)
+	if err != nil {
+		t.Fatalf("wait ready: %v", err)
+	}
+	if ready.State != JobReady {
+		t.Fatalf("expected job to be ready, got %v", ready.State)
+	}
+}
+
+func TestWaitReadyTimesOut(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return time.Minute }
+
+	job, err := m.StartJob(ProductCoffee, "", 0, "")
+	if err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := m.WaitReady(ctx, job.JobID); err == nil {
+		t.Fatalf("expected wait to time out")
+	}
+}
+
+func TestSubscribeReceivesJobLifecycleEvents(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 10 * time.Millisecond }
+
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+
+	job, err := m.StartJob(ProductCoffee, "", 0, "")
+	if err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	deadline := time.After(time.Second)
+	for !seen[string(eventStarted)] || !seen[string(eventReady)] {
+		select {
+		case ev := <-events:
+			if ev.Job.JobID == job.JobID {
+				seen[ev.Type] = true
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for events, saw %v", seen)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// brewDurationBuckets and retrievalLatencyBuckets are the histogram bucket
+// boundaries (in seconds) prometheus renders as "le" buckets.
+var (
+	brewDurationBuckets     = []float64{5, 10, 20, 30, 45, 60, 90, 120}
+	retrievalLatencyBuckets = []float64{1, 5, 15, 30, 60, 300, 900}
+)
+
+// Metrics is the instrumentation surface Machine reports through. NewMachine
+// installs an in-process collector by default; tests can pass noopMetrics
+// via NewMachineWithMetrics to skip the bookkeeping entirely.
+type Metrics interface {
+	JobStarted(product Product)
+	JobRetrieved(product Product)
+	JobFailed(reason string)
+	ObserveBrewDuration(product Product, d time.Duration)
+	ObserveRetrievalLatency(d time.Duration)
+	HookAttempt(status string)
+	SetQueueDepth(n int)
+	SetActiveBrews(n int)
+	SetBlockedSlots(n int)
+	SetJobsByState(state JobState, n int)
+	SetMachineStatus(state MachineState)
+	Render(w io.Writer) error
+}
+
+// noopMetrics discards every observation; useful in tests that don't care
+// about instrumentation.
+type noopMetrics struct{}
+
+func (noopMetrics) JobStarted(Product)                         {}
+func (noopMetrics) JobRetrieved(Product)                       {}
+func (noopMetrics) JobFailed(string)                           {}
+func (noopMetrics) ObserveBrewDuration(Product, time.Duration) {}
+func (noopMetrics) ObserveRetrievalLatency(time.Duration)      {}
+func (noopMetrics) HookAttempt(string)                         {}
+func (noopMetrics) SetQueueDepth(int)                          {}
+func (noopMetrics) SetActiveBrews(int)                         {}
+func (noopMetrics) SetBlockedSlots(int)                        {}
+func (noopMetrics) SetJobsByState(JobState, int)               {}
+func (noopMetrics) SetMachineStatus(MachineState)              {}
+func (noopMetrics) Render(io.Writer) error                     { return nil }
+
+// inProcessMetrics is a prometheus/client_golang registry scoped to a single
+// Machine: every counter, gauge, and histogram /metrics reports is a real
+// prometheus collector, and Render serves them through the standard text
+// exposition encoder rather than hand-formatting lines.
+type inProcessMetrics struct {
+	registry *prometheus.Registry
+
+	jobsStarted      *prometheus.CounterVec
+	jobsRetrieved    *prometheus.CounterVec
+	jobsFailed       *prometheus.CounterVec
+	brewDuration     *prometheus.HistogramVec
+	retrievalLatency prometheus.Histogram
+	hookAttempts     *prometheus.CounterVec
+
+	queueDepth    prometheus.Gauge
+	activeBrews   prometheus.Gauge
+	blockedSlots  prometheus.Gauge
+	jobsByState   *prometheus.GaugeVec
+	machineStatus prometheus.Gauge
+}
+
+func newMetrics() *inProcessMetrics {
+	m := &inProcessMetrics{
+		registry: prometheus.NewRegistry(),
+
+		jobsStarted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "coffee_jobs_started_total",
+			Help: "Jobs accepted by product.",
+		}, []string{"product"}),
+		jobsRetrieved: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "coffee_jobs_retrieved_total",
+			Help: "Jobs retrieved by product.",
+		}, []string{"product"}),
+		jobsFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "coffee_jobs_failed_total",
+			Help: "Jobs rejected, cancelled, or expired, by reason.",
+		}, []string{"reason"}),
+		brewDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "coffee_brew_duration_seconds",
+			Help:    "Time spent brewing, by product.",
+			Buckets: brewDurationBuckets,
+		}, []string{"product"}),
+		retrievalLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "coffee_retrieval_latency_seconds",
+			Help:    "Time between a job becoming ready and being retrieved.",
+			Buckets: retrievalLatencyBuckets,
+		}),
+		hookAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "coffee_machine_hook_attempts_total",
+			Help: "Webhook callback delivery attempts by outcome.",
+		}, []string{"status"}),
+
+		queueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "coffee_queue_depth",
+			Help: "Jobs waiting for a free brew head.",
+		}),
+		activeBrews: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "coffee_active_brews",
+			Help: "Brew heads currently brewing.",
+		}),
+		blockedSlots: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "coffee_blocked_slots",
+			Help: "Brew heads holding a ready job that hasn't been retrieved.",
+		}),
+		jobsByState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "coffee_jobs_by_state",
+			Help: "Jobs currently held in memory, by state.",
+		}, []string{"state"}),
+		machineStatus: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "coffee_machine_status",
+			Help: "Overall machine status (StateAvailable=0, StateBrewing=1, StateBlocked=2).",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.jobsStarted, m.jobsRetrieved, m.jobsFailed,
+		m.brewDuration, m.retrievalLatency, m.hookAttempts,
+		m.queueDepth, m.activeBrews, m.blockedSlots, m.jobsByState, m.machineStatus,
+	)
+
+	return m
+}
+
+func (m *inProcessMetrics) JobStarted(product Product) {
+	m.jobsStarted.WithLabelValues(string(product)).Inc()
+}
+
+func (m *inProcessMetrics) JobRetrieved(product Product) {
+	m.jobsRetrieved.WithLabelValues(string(product)).Inc()
+}
+
+func (m *inProcessMetrics) JobFailed(reason string) {
+	m.jobsFailed.WithLabelValues(reason).Inc()
+}
+
+func (m *inProcessMetrics) ObserveBrewDuration(product Product, d time.Duration) {
+	m.brewDuration.WithLabelValues(string(product)).Observe(d.Seconds())
+}
+
+func (m *inProcessMetrics) ObserveRetrievalLatency(d time.Duration) {
+	m.retrievalLatency.Observe(d.Seconds())
+}
+
+func (m *inProcessMetrics) HookAttempt(status string) {
+	m.hookAttempts.WithLabelValues(status).Inc()
+}
+
+func (m *inProcessMetrics) SetQueueDepth(n int)   { m.queueDepth.Set(float64(n)) }
+func (m *inProcessMetrics) SetActiveBrews(n int)  { m.activeBrews.Set(float64(n)) }
+func (m *inProcessMetrics) SetBlockedSlots(n int) { m.blockedSlots.Set(float64(n)) }
+
+func (m *inProcessMetrics) SetJobsByState(state JobState, n int) {
+	m.jobsByState.WithLabelValues(string(state)).Set(float64(n))
+}
+
+func (m *inProcessMetrics) SetMachineStatus(state MachineState) {
+	m.machineStatus.Set(float64(state))
+}
+
+// Render gathers every registered collector and writes it in the Prometheus
+// text exposition format, the same format client_golang's promhttp.Handler
+// would serve.
+func (m *inProcessMetrics) Render(w io.Writer) error {
+	families, err := m.registry.Gather()
+	if err != nil {
+		return err
+	}
+
+	enc := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, family := range families {
+		if err := enc.Encode(family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
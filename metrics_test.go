@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInProcessMetricsCountsByProduct(t *testing.T) {
+	m := newMetrics()
+	m.JobStarted(ProductCoffee)
+	m.JobStarted(ProductCoffee)
+	m.JobStarted(ProductEspresso)
+	m.JobFailed("unsupported_product")
+
+	var sb strings.Builder
+	if err := m.Render(&sb); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	out := sb.String()
+
+	if !strings.Contains(out, `coffee_jobs_started_total{product="COFFEE"} 2`) {
+		t.Fatalf("expected COFFEE counter at 2, got:\n%s", out)
+	}
+	if !strings.Contains(out, `coffee_jobs_started_total{product="ESPRESSO"} 1`) {
+		t.Fatalf("expected ESPRESSO counter at 1, got:\n%s", out)
+	}
+	if !strings.Contains(out, `coffee_jobs_failed_total{reason="unsupported_product"} 1`) {
+		t.Fatalf("expected failure counter at 1, got:\n%s", out)
+	}
+}
+
+func TestMachineReportsStartAndRetrieveMetrics(t *testing.T) {
+	metrics := newMetrics()
+	m := NewMachineWithMetrics(1, NewMemoryStore(), metrics)
+	m.brewTimeFn = func() time.Duration { return time.Millisecond }
+
+	job, err := m.StartJob(ProductCoffee, "", 0, "")
+	if err != nil {
+		t.Fatalf("StartJob: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		if status, _ := m.RetrieveJob(job.JobID); status.State == JobRetrieved {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for job to become ready")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	var sb strings.Builder
+	if err := metrics.Render(&sb); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	out := sb.String()
+
+	if !strings.Contains(out, `coffee_jobs_started_total{product="COFFEE"} 1`) {
+		t.Fatalf("expected started counter at 1, got:\n%s", out)
+	}
+	if !strings.Contains(out, `coffee_jobs_retrieved_total{product="COFFEE"} 1`) {
+		t.Fatalf("expected retrieved counter at 1, got:\n%s", out)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import "sync"
+
+// Event is a single phase or completion update delivered to subscribers of
+// a brewing job's progress stream (GET /jobs/{jobID}/events).
+type Event struct {
+	Type     string   `json:"event"` // "phase" or "done"
+	JobID    string   `json:"jobID"`
+	Phase    string   `json:"phase"`
+	Progress int      `json:"progress"`
+	State    JobState `json:"state,omitempty"`
+}
+
+// progressEventBuffer bounds how many updates a slow subscriber can lag
+// behind before newer ones are dropped rather than blocking the brewing
+// path.
+const progressEventBuffer = 16
+
+// defaultPhases names the sub-phases a brewing job passes through, in
+// order, for products with no override in phasesByProduct.
+var defaultPhases = []string{"grinding", "heating", "brewing"}
+
+// phasesByProduct overrides defaultPhases for specific products; products
+// not listed here use defaultPhases.
+var phasesByProduct = map[Product][]string{}
+
+func phasesForProduct(product Product) []string {
+	if phases, ok := phasesByProduct[product]; ok {
+		return phases
+	}
+	return defaultPhases
+}
+
+// jobProgress tracks the current phase of one brewing job and fans its
+// updates out to every subscriber.
+type jobProgress struct {
+	mu       sync.Mutex
+	jobID    string
+	phase    string
+	progress int
+	state    JobState
+	done     bool
+	subs     map[chan Event]struct{}
+}
+
+func newJobProgress(jobID, phase string) *jobProgress {
+	return &jobProgress{
+		jobID: jobID,
+		phase: phase,
+		state: JobBrewing,
+		subs:  make(map[chan Event]struct{}),
+	}
+}
+
+// subscribe registers a channel that first receives the current phase, so a
+// subscriber attaching mid-brew isn't left waiting for the next transition,
+// then every subsequent update. A subscription on an already-finished job
+// immediately receives its terminal event and a closed channel.
+//
+// The initial send (and, for an already-finished job, the close) happens
+// while still holding p.mu, in the same critical section that registers ch
+// in p.subs. That's what stops it from racing a concurrent finish: finish
+// also sends-and-closes under p.mu, so the two can never interleave on the
+// same channel.
+func (p *jobProgress) subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, progressEventBuffer)
+
+	p.mu.Lock()
+	current := Event{Type: "phase", JobID: p.jobID, Phase: p.phase, Progress: p.progress, State: p.state}
+	if p.done {
+		current.Type = "done"
+		ch <- current
+		close(ch)
+		p.mu.Unlock()
+		return ch, func() {}
+	}
+
+	p.subs[ch] = struct{}{}
+	ch <- current
+	p.mu.Unlock()
+
+	unsubscribe := func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.subs, ch)
+	}
+	return ch, unsubscribe
+}
+
+// setState updates the terminal state recorded for a job after it has
+// finished, e.g. when a ready job is later retrieved, so that a subscriber
+// arriving afterwards sees the job's current state rather than the state it
+// had when finish was first called.
+func (p *jobProgress) setState(state JobState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state = state
+}
+
+// update advances the tracked phase/progress and broadcasts it to every
+// current subscriber, dropping the update for any that aren't keeping up
+// rather than blocking the brewing path.
+func (p *jobProgress) update(phase string, progress int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done {
+		return
+	}
+
+	p.phase = phase
+	p.progress = progress
+	ev := Event{Type: "phase", JobID: p.jobID, Phase: phase, Progress: progress, State: p.state}
+	for ch := range p.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// finish marks the job's progress as terminal, broadcasts a final "done"
+// event carrying state, and closes every subscriber channel so connections
+// close cleanly once the job reaches a terminal state.
+func (p *jobProgress) finish(state JobState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done {
+		return
+	}
+
+	p.done = true
+	p.state = state
+	p.progress = 100
+	p.phase = "ready"
+
+	ev := Event{Type: "done", JobID: p.jobID, Phase: "ready", Progress: 100, State: state}
+	for ch := range p.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+		close(ch)
+	}
+	p.subs = make(map[chan Event]struct{})
+}
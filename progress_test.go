@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubscribeJobEventsMidBrewReceivesCurrentPhaseFirst(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 60 * time.Millisecond }
+
+	job, err := m.StartJob(ProductCoffee, "", 0, "")
+	if err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+
+	// Let the job advance past its first phase before subscribing.
+	time.Sleep(30 * time.Millisecond)
+
+	events, unsubscribe, err := m.SubscribeJobEvents(job.JobID)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer unsubscribe()
+
+	select {
+	case ev := <-events:
+		if ev.Phase == "" {
+			t.Fatalf("expected a non-empty current phase, got %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for current-phase event")
+	}
+}
+
+func TestSubscribeJobEventsTwoSubscribersBothSeeAllEvents(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 30 * time.Millisecond }
+
+	job, err := m.StartJob(ProductCoffee, "", 0, "")
+	if err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+
+	eventsA, unsubscribeA, err := m.SubscribeJobEvents(job.JobID)
+	if err != nil {
+		t.Fatalf("subscribe a: %v", err)
+	}
+	defer unsubscribeA()
+
+	eventsB, unsubscribeB, err := m.SubscribeJobEvents(job.JobID)
+	if err != nil {
+		t.Fatalf("subscribe b: %v", err)
+	}
+	defer unsubscribeB()
+
+	drainUntilDone := func(ch <-chan Event) []Event {
+		var got []Event
+		deadline := time.After(2 * time.Second)
+		for {
+			select {
+			case ev, ok := <-ch:
+				if !ok {
+					return got
+				}
+				got = append(got, ev)
+				if ev.Type == "done" {
+					return got
+				}
+			case <-deadline:
+				t.Fatal("timed out waiting for job events")
+			}
+		}
+	}
+
+	gotA := drainUntilDone(eventsA)
+	gotB := drainUntilDone(eventsB)
+
+	if len(gotA) == 0 || gotA[len(gotA)-1].Type != "done" {
+		t.Fatalf("expected subscriber A to see a terminal done event, got %+v", gotA)
+	}
+	if len(gotB) == 0 || gotB[len(gotB)-1].Type != "done" {
+		t.Fatalf("expected subscriber B to see a terminal done event, got %+v", gotB)
+	}
+	if len(gotA) != len(gotB) {
+		t.Fatalf("expected both subscribers to see the same events, got %+v and %+v", gotA, gotB)
+	}
+	for i := range gotA {
+		if gotA[i] != gotB[i] {
+			t.Fatalf("expected both subscribers to see the same events, got %+v and %+v", gotA, gotB)
+		}
+	}
+}
+
+func TestSubscribeJobEventsRetrievedJobGetsImmediateDone(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return time.Millisecond }
+
+	job, err := m.StartJob(ProductCoffee, "", 0, "")
+	if err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := m.WaitReady(ctx, job.JobID); err != nil {
+		t.Fatalf("wait ready: %v", err)
+	}
+	if _, err := m.RetrieveJob(job.JobID); err != nil {
+		t.Fatalf("retrieve job: %v", err)
+	}
+
+	events, unsubscribe, err := m.SubscribeJobEvents(job.JobID)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer unsubscribe()
+
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatal("expected a done event before the channel closed")
+		}
+		if ev.Type != "done" || ev.State != JobRetrieved {
+			t.Fatalf("expected an immediate done event with state JobRetrieved, got %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for done event")
+	}
+
+	if _, ok := <-events; ok {
+		t.Fatal("expected channel to be closed after the done event")
+	}
+}
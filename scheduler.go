@@ -0,0 +1,437 @@
+package main
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// schedulerTickInterval is how often Scheduler checks whether its next
+// schedule is due to fire.
+const schedulerTickInterval = time.Second
+
+// Schedule describes a one-shot (RunAt) or recurring (Cron) brew request.
+// Exactly one of RunAt or Cron is set. Scheduler fires it by calling the
+// owning Machine's StartJob.
+type Schedule struct {
+	ScheduleID  string     `json:"scheduleId"`
+	Product     Product    `json:"product"`
+	Priority    int        `json:"priority,omitempty"`
+	CallbackURL string     `json:"callbackUrl,omitempty"`
+	RunAt       *time.Time `json:"runAt,omitempty"`
+	Cron        string     `json:"cron,omitempty"`
+	NextFire    time.Time  `json:"nextFire"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// scheduleHeap is a min-heap of *Schedule ordered by NextFire, letting
+// Scheduler find the next due schedule without scanning every entry.
+type scheduleHeap []*Schedule
+
+func (h scheduleHeap) Len() int           { return len(h) }
+func (h scheduleHeap) Less(i, j int) bool { return h[i].NextFire.Before(h[j].NextFire) }
+func (h scheduleHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *scheduleHeap) Push(x any) {
+	*h = append(*h, x.(*Schedule))
+}
+
+func (h *scheduleHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
+
+// Scheduler fires scheduled and recurring brew jobs onto a Machine. A single
+// goroutine maintains a min-heap keyed by next-fire time and ticks to check
+// for due schedules, so a large backlog of schedules never blocks the
+// caller submitting them. Schedules are persisted to a JSON file so they
+// survive restarts.
+type Scheduler struct {
+	mu      sync.Mutex
+	machine *Machine
+	heap    scheduleHeap
+	byID    map[string]*Schedule
+	path    string
+	stop    chan struct{}
+}
+
+// NewScheduler constructs a Scheduler for machine. If path is non-empty, any
+// previously persisted schedules are loaded from it and a missed recurrence
+// (at most one) is fired immediately for each that fell due while the
+// process was down; a recurrence missed by more than cronMinInterval is
+// skipped rather than backfilled, whatever the schedule's own recurrence
+// interval. An empty path disables persistence.
+func NewScheduler(machine *Machine, path string) (*Scheduler, error) {
+	s := &Scheduler{
+		machine: machine,
+		byID:    make(map[string]*Schedule),
+		path:    path,
+		stop:    make(chan struct{}),
+	}
+	heap.Init(&s.heap)
+
+	if path != "" {
+		schedules, err := loadSchedules(path)
+		if err != nil {
+			return nil, fmt.Errorf("load schedules: %w", err)
+		}
+		now := machine.nowFn()
+		for _, sch := range schedules {
+			s.reviveLocked(sch, now)
+		}
+	}
+
+	go s.run()
+	return s, nil
+}
+
+// reviveLocked re-inserts a schedule loaded from disk, catching up at most
+// one missed recurrence if its NextFire has already passed.
+func (s *Scheduler) reviveLocked(sch *Schedule, now time.Time) {
+	if sch.NextFire.After(now) {
+		s.byID[sch.ScheduleID] = sch
+		heap.Push(&s.heap, sch)
+		return
+	}
+
+	if sch.Cron == "" {
+		// One-shot schedules that fired (or were missed) before restart are
+		// fired once on startup rather than silently dropped.
+		s.byID[sch.ScheduleID] = sch
+		heap.Push(&s.heap, sch)
+		return
+	}
+
+	cron, err := parseCron(sch.Cron)
+	if err != nil {
+		return
+	}
+	interval := cronMinInterval
+	if now.Sub(sch.NextFire) <= interval {
+		// Missed by less than one interval: fire it once, then resume its
+		// normal recurrence.
+		s.byID[sch.ScheduleID] = sch
+		heap.Push(&s.heap, sch)
+		return
+	}
+
+	next, err := cron.next(now)
+	if err != nil {
+		return
+	}
+	sch.NextFire = next
+	s.byID[sch.ScheduleID] = sch
+	heap.Push(&s.heap, sch)
+}
+
+// cronMinInterval bounds how far in the past a recurring schedule's missed
+// NextFire can be before Scheduler gives up backfilling it and instead
+// resumes from the next regular occurrence.
+const cronMinInterval = time.Minute
+
+// Add validates and registers a new schedule, persists the schedule set, and
+// returns the created Schedule.
+func (s *Scheduler) Add(product Product, priority int, callbackURL string, runAt *time.Time, cron string) (Schedule, error) {
+	if !product.valid() {
+		return Schedule{}, ErrUnsupportedProduct
+	}
+	if (runAt == nil) == (cron == "") {
+		return Schedule{}, fmt.Errorf("exactly one of runAt or cron must be set")
+	}
+
+	var nextFire time.Time
+	if runAt != nil {
+		nextFire = *runAt
+	} else {
+		parsed, err := parseCron(cron)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("invalid cron spec: %w", err)
+		}
+		nextFire, err = parsed.next(s.machine.nowFn())
+		if err != nil {
+			return Schedule{}, err
+		}
+	}
+
+	id, err := generateJobID()
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	sch := &Schedule{
+		ScheduleID:  id,
+		Product:     product,
+		Priority:    priority,
+		CallbackURL: callbackURL,
+		RunAt:       runAt,
+		Cron:        cron,
+		NextFire:    nextFire,
+		CreatedAt:   s.machine.nowFn(),
+	}
+
+	s.mu.Lock()
+	s.byID[sch.ScheduleID] = sch
+	heap.Push(&s.heap, sch)
+	err = s.persistLocked()
+	s.mu.Unlock()
+
+	if err != nil {
+		return Schedule{}, err
+	}
+	return *sch, nil
+}
+
+// List returns every currently registered schedule.
+func (s *Scheduler) List() []Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Schedule, 0, len(s.byID))
+	for _, sch := range s.byID {
+		out = append(out, *sch)
+	}
+	return out
+}
+
+// Delete removes a pending schedule by ID.
+func (s *Scheduler) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.byID[id]; !ok {
+		return ErrJobNotFound
+	}
+	delete(s.byID, id)
+
+	for i, sch := range s.heap {
+		if sch.ScheduleID == id {
+			heap.Remove(&s.heap, i)
+			break
+		}
+	}
+
+	return s.persistLocked()
+}
+
+// Close stops the scheduler's background goroutine.
+func (s *Scheduler) Close() {
+	close(s.stop)
+}
+
+func (s *Scheduler) run() {
+	ticker := time.NewTicker(schedulerTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.fireDue()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// fireDue starts a job for every schedule whose NextFire has passed,
+// rescheduling recurring ones and removing one-shot ones.
+func (s *Scheduler) fireDue() {
+	now := s.machine.nowFn()
+
+	for {
+		s.mu.Lock()
+		if s.heap.Len() == 0 || s.heap[0].NextFire.After(now) {
+			s.mu.Unlock()
+			return
+		}
+		sch := s.heap[0]
+		heap.Pop(&s.heap)
+
+		if sch.Cron == "" {
+			delete(s.byID, sch.ScheduleID)
+		} else {
+			cron, err := parseCron(sch.Cron)
+			if err == nil {
+				if next, err := cron.next(now); err == nil {
+					sch.NextFire = next
+					heap.Push(&s.heap, sch)
+				} else {
+					delete(s.byID, sch.ScheduleID)
+				}
+			} else {
+				delete(s.byID, sch.ScheduleID)
+			}
+		}
+		_ = s.persistLocked()
+		s.mu.Unlock()
+
+		// StartJob already queues the job if the machine is busy, so firing
+		// a due schedule never blocks waiting for a free brew head. If the
+		// queue is at its SetQueueCap bound, StartJob's error is discarded and
+		// this firing starts no job.
+		_, _ = s.machine.StartJob(sch.Product, "", sch.Priority, sch.CallbackURL)
+	}
+}
+
+func (s *Scheduler) persistLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	schedules := make([]Schedule, 0, len(s.byID))
+	for _, sch := range s.byID {
+		schedules = append(schedules, *sch)
+	}
+
+	data, err := json.Marshal(schedules)
+	if err != nil {
+		return fmt.Errorf("encode schedules: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write schedules: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func loadSchedules(path string) ([]*Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var schedules []*Schedule
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return nil, fmt.Errorf("decode schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// cronField matches one field of a 5-field cron spec: minute, hour,
+// day-of-month, month, or day-of-week.
+type cronField struct {
+	any    bool
+	values map[int]bool
+}
+
+func (f cronField) matches(v int) bool {
+	return f.any || f.values[v]
+}
+
+func parseCronField(field string, min, max int) (cronField, error) {
+	if field == "*" {
+		return cronField{any: true}, nil
+	}
+
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		switch {
+		case strings.HasPrefix(part, "*/"):
+			step, err := strconv.Atoi(strings.TrimPrefix(part, "*/"))
+			if err != nil || step <= 0 {
+				return cronField{}, fmt.Errorf("invalid step %q", part)
+			}
+			for v := min; v <= max; v += step {
+				values[v] = true
+			}
+		case strings.Contains(part, "-"):
+			lo, hi, ok := strings.Cut(part, "-")
+			loN, err1 := strconv.Atoi(lo)
+			hiN, err2 := strconv.Atoi(hi)
+			if !ok || err1 != nil || err2 != nil || loN > hiN || loN < min || hiN > max {
+				return cronField{}, fmt.Errorf("invalid range %q", part)
+			}
+			for v := loN; v <= hiN; v++ {
+				values[v] = true
+			}
+		default:
+			v, err := strconv.Atoi(part)
+			if err != nil || v < min || v > max {
+				return cronField{}, fmt.Errorf("invalid value %q", part)
+			}
+			values[v] = true
+		}
+	}
+
+	if len(values) == 0 {
+		return cronField{}, fmt.Errorf("empty field")
+	}
+	return cronField{values: values}, nil
+}
+
+// cronSchedule is a parsed standard 5-field cron spec (minute hour
+// day-of-month month day-of-week). Day-of-month and day-of-week are
+// combined with AND rather than cron's traditional OR-when-both-restricted
+// rule, which covers every spec this API is expected to see without the
+// added complexity of the full rule.
+type cronSchedule struct {
+	minute  cronField
+	hour    cronField
+	day     cronField
+	month   cronField
+	weekday cronField
+}
+
+// cronSearchLimit bounds how far into the future next will search before
+// giving up; a valid 5-field spec always matches well within this window.
+const cronSearchLimit = 4 * 365 * 24 * time.Hour
+
+func parseCron(spec string) (*cronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron spec must have 5 fields, got %d", len(fields))
+	}
+
+	minute, err := parseCronField(fields[0], 0, 59)
+	if err != nil {
+		return nil, fmt.Errorf("minute field: %w", err)
+	}
+	hour, err := parseCronField(fields[1], 0, 23)
+	if err != nil {
+		return nil, fmt.Errorf("hour field: %w", err)
+	}
+	day, err := parseCronField(fields[2], 1, 31)
+	if err != nil {
+		return nil, fmt.Errorf("day field: %w", err)
+	}
+	month, err := parseCronField(fields[3], 1, 12)
+	if err != nil {
+		return nil, fmt.Errorf("month field: %w", err)
+	}
+	weekday, err := parseCronField(fields[4], 0, 6)
+	if err != nil {
+		return nil, fmt.Errorf("weekday field: %w", err)
+	}
+
+	return &cronSchedule{minute: minute, hour: hour, day: day, month: month, weekday: weekday}, nil
+}
+
+// next returns the first minute-aligned instant strictly after after that
+// matches the spec.
+func (c *cronSchedule) next(after time.Time) (time.Time, error) {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	deadline := after.Add(cronSearchLimit)
+
+	for t.Before(deadline) {
+		if c.month.matches(int(t.Month())) && c.day.matches(t.Day()) &&
+			c.weekday.matches(int(t.Weekday())) && c.hour.matches(t.Hour()) &&
+			c.minute.matches(t.Minute()) {
+			return t, nil
+		}
+		t = t.Add(time.Minute)
+	}
+
+	return time.Time{}, fmt.Errorf("no matching time found within search window")
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSchedulerFiresOneShotSchedule(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return time.Hour }
+
+	fakeNow := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.nowFn = func() time.Time { return fakeNow }
+
+	scheduler, err := NewScheduler(m, "")
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	defer scheduler.Close()
+
+	runAt := fakeNow.Add(10 * time.Second)
+	if _, err := scheduler.Add(ProductCoffee, 0, "", &runAt, ""); err != nil {
+		t.Fatalf("add schedule: %v", err)
+	}
+
+	scheduler.fireDue()
+	if len(m.History()) != 0 {
+		t.Fatalf("expected schedule not due yet to not have fired, got history %+v", m.History())
+	}
+
+	fakeNow = fakeNow.Add(11 * time.Second)
+	scheduler.fireDue()
+
+	if len(m.History()) != 1 {
+		t.Fatalf("expected scheduled job to have fired, got history %+v", m.History())
+	}
+	if len(scheduler.List()) != 0 {
+		t.Fatalf("expected one-shot schedule to be removed after firing, got %+v", scheduler.List())
+	}
+}
+
+// TestSchedulerSkipsBackfillForCronMissedByMoreThanOneInterval covers the
+// restart-recovery branch in reviveLocked: a recurring schedule whose
+// NextFire fell due more than cronMinInterval before the process restarted
+// must not be fired immediately to "catch up" - it should resume from its
+// next regular occurrence instead.
+func TestSchedulerSkipsBackfillForCronMissedByMoreThanOneInterval(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schedules.json")
+
+	before := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m1 := NewMachine()
+	m1.nowFn = func() time.Time { return before }
+
+	scheduler1, err := NewScheduler(m1, path)
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	sch, err := scheduler1.Add(ProductCoffee, 0, "", nil, "* * * * *")
+	if err != nil {
+		t.Fatalf("add schedule: %v", err)
+	}
+	scheduler1.Close()
+
+	// The process comes back up long after the missed recurrence - well
+	// beyond cronMinInterval past sch.NextFire.
+	after := sch.NextFire.Add(cronMinInterval * 10)
+	m2 := NewMachine()
+	m2.nowFn = func() time.Time { return after }
+
+	scheduler2, err := NewScheduler(m2, path)
+	if err != nil {
+		t.Fatalf("reload scheduler: %v", err)
+	}
+	defer scheduler2.Close()
+
+	recovered := scheduler2.List()
+	if len(recovered) != 1 || recovered[0].ScheduleID != sch.ScheduleID {
+		t.Fatalf("expected recovered schedule %s, got %+v", sch.ScheduleID, recovered)
+	}
+	if !recovered[0].NextFire.After(after) {
+		t.Fatalf("expected missed recurrence to be skipped in favour of the next occurrence after %v, got NextFire %v", after, recovered[0].NextFire)
+	}
+
+	scheduler2.fireDue()
+	if len(m2.History()) != 0 {
+		t.Fatalf("expected no backfilled job to have fired, got history %+v", m2.History())
+	}
+}
+
+func TestSchedulerRejectsInvalidCron(t *testing.T) {
+	m := NewMachine()
+	scheduler, err := NewScheduler(m, "")
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	defer scheduler.Close()
+
+	if _, err := scheduler.Add(ProductCoffee, 0, "", nil, "not a cron spec"); err == nil {
+		t.Fatalf("expected invalid cron spec to be rejected")
+	}
+}
+
+func TestSchedulerRejectsBothOrNeitherRunAtAndCron(t *testing.T) {
+	m := NewMachine()
+	scheduler, err := NewScheduler(m, "")
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	defer scheduler.Close()
+
+	if _, err := scheduler.Add(ProductCoffee, 0, "", nil, ""); err == nil {
+		t.Fatalf("expected an error when neither runAt nor cron is set")
+	}
+
+	runAt := time.Now().Add(time.Hour)
+	if _, err := scheduler.Add(ProductCoffee, 0, "", &runAt, "* * * * *"); err == nil {
+		t.Fatalf("expected an error when both runAt and cron are set")
+	}
+}
+
+func TestSchedulerDeletesPendingSchedule(t *testing.T) {
+	m := NewMachine()
+	scheduler, err := NewScheduler(m, "")
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+	defer scheduler.Close()
+
+	runAt := time.Now().Add(time.Hour)
+	sch, err := scheduler.Add(ProductCoffee, 0, "", &runAt, "")
+	if err != nil {
+		t.Fatalf("add schedule: %v", err)
+	}
+
+	if err := scheduler.Delete(sch.ScheduleID); err != nil {
+		t.Fatalf("delete schedule: %v", err)
+	}
+	if len(scheduler.List()) != 0 {
+		t.Fatalf("expected schedule to be removed, got %+v", scheduler.List())
+	}
+
+	if err := scheduler.Delete(sch.ScheduleID); err == nil {
+		t.Fatalf("expected deleting an already-removed schedule to fail")
+	}
+}
+
+func TestSchedulerRecoversAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schedules.json")
+
+	m1 := NewMachine()
+	scheduler1, err := NewScheduler(m1, path)
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+
+	runAt := time.Now().Add(time.Hour)
+	sch, err := scheduler1.Add(ProductCoffee, 0, "", &runAt, "")
+	if err != nil {
+		t.Fatalf("add schedule: %v", err)
+	}
+	scheduler1.Close()
+
+	m2 := NewMachine()
+	scheduler2, err := NewScheduler(m2, path)
+	if err != nil {
+		t.Fatalf("reload scheduler: %v", err)
+	}
+	defer scheduler2.Close()
+
+	recovered := scheduler2.List()
+	if len(recovered) != 1 || recovered[0].ScheduleID != sch.ScheduleID {
+		t.Fatalf("expected recovered schedule %s, got %+v", sch.ScheduleID, recovered)
+	}
+}
+
+func TestParseCronRejectsWrongFieldCount(t *testing.T) {
+	if _, err := parseCron("* * *"); err == nil {
+		t.Fatalf("expected a 3-field spec to be rejected")
+	}
+}
+
+func TestParseCronComputesNextFire(t *testing.T) {
+	cron, err := parseCron("*/15 * * * *")
+	if err != nil {
+		t.Fatalf("parse cron: %v", err)
+	}
+
+	after := time.Date(2026, 1, 1, 0, 1, 30, 0, time.UTC)
+	next, err := cron.next(after)
+	if err != nil {
+		t.Fatalf("next: %v", err)
+	}
+	want := time.Date(2026, 1, 1, 0, 15, 0, 0, time.UTC)
+	if !next.Equal(want) {
+		t.Fatalf("expected next fire %v, got %v", want, next)
+	}
+}
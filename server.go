@@ -4,24 +4,32 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
 
 type server struct {
-	machine *Machine
-	logger  *log.Logger
+	machine   *Machine
+	scheduler *Scheduler
+	logger    *log.Logger
 }
 
-func newServer(machine *Machine, logger *log.Logger) http.Handler {
+// newServer builds the coffeepot HTTP API. jwtKey enables JWT auth on every
+// route except /healthz and /readyz when non-empty; an empty key leaves auth
+// disabled, matching pre-JWT behaviour.
+func newServer(machine *Machine, scheduler *Scheduler, logger *log.Logger, jwtKey []byte) http.Handler {
 	s := &server{
-		machine: machine,
-		logger:  logger,
+		machine:   machine,
+		scheduler: scheduler,
+		logger:    logger,
 	}
 
 	mux := http.NewServeMux()
@@ -30,11 +38,20 @@ func newServer(machine *Machine, logger *log.Logger) http.Handler {
 	mux.HandleFunc("/status", s.handleStatus)
 	mux.HandleFunc("/start-job", s.handleStartJob)
 	mux.HandleFunc("/retrieve-job", s.handleRetrieveJob)
+	mux.HandleFunc("/cancel-job", s.handleCancelJob)
+	mux.HandleFunc("/jobs/", s.handleJobByID)
+	mux.HandleFunc("/schedule", s.handleSchedule)
+	mux.HandleFunc("/schedules", s.handleSchedules)
+	mux.HandleFunc("/schedules/", s.handleScheduleByID)
+	mux.HandleFunc("/queue", s.handleQueue)
 	mux.HandleFunc("/history", s.handleHistory)
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.HandleFunc("/hooks/status", s.handleHooksStatus)
+	mux.HandleFunc("/admin/reset", s.handleAdminReset)
 	mux.HandleFunc("/metrics", s.handleMetrics)
 	mux.HandleFunc("/openapi.yaml", s.handleOpenAPI)
 
-	return loggingMiddleware(logger, mux)
+	return loggingMiddleware(logger, authMiddleware(mux, jwtKey))
 }
 
 func (s *server) handleHealthz(w http.ResponseWriter, r *http.Request) {
@@ -53,16 +70,12 @@ func (s *server) handleReadyz(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
-	state := s.machine.Status()
-	payload := map[string]any{
-		"state": stateString(state),
-		"code":  int(state),
-	}
+	report := s.machine.StatusReport()
 	status := http.StatusOK
-	if state != StateAvailable {
+	if report.Heads > 0 && report.BlockedSlots == report.Heads {
 		status = http.StatusServiceUnavailable
 	}
-	writeJSON(w, status, payload)
+	writeJSON(w, status, report)
 }
 
 func (s *server) handleStartJob(w http.ResponseWriter, r *http.Request) {
@@ -72,8 +85,10 @@ func (s *server) handleStartJob(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		JobID   string  `json:"jobId"`
-		Product Product `json:"product"`
+		JobID       string  `json:"jobId"`
+		Product     Product `json:"product"`
+		Priority    int     `json:"priority,omitempty"`
+		CallbackURL string  `json:"callbackUrl,omitempty"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -83,10 +98,15 @@ func (s *server) handleStartJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	job, err := s.machine.StartJob(req.Product, req.JobID)
+	job, err := s.machine.StartJob(req.Product, req.JobID, req.Priority, req.CallbackURL)
 	if err != nil {
 		switch {
 		case errors.Is(err, ErrMachineBusy):
+			retryAfter := time.Until(s.machine.NextAvailableAt())
+			if retryAfter < time.Second {
+				retryAfter = time.Second
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
 			http.Error(w, "machine unavailable", http.StatusServiceUnavailable)
 		case errors.Is(err, ErrUnsupportedProduct):
 			http.Error(w, "unsupported product", http.StatusBadRequest)
@@ -101,6 +121,191 @@ func (s *server) handleStartJob(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusAccepted, job)
 }
 
+func (s *server) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jobID := r.URL.Query().Get("jobId")
+	if jobID == "" {
+		http.Error(w, "missing jobId", http.StatusBadRequest)
+		return
+	}
+
+	s.cancelJob(w, jobID)
+}
+
+// handleJobByID serves DELETE /jobs/{jobID}, an alternative to /cancel-job
+// that names the job as a path segment instead of a query parameter, and
+// GET /jobs/{jobID}/events, which streams the job's brewing progress.
+func (s *server) handleJobByID(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/jobs/")
+
+	if jobID, ok := strings.CutSuffix(rest, "/events"); ok {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if jobID == "" || strings.Contains(jobID, "/") {
+			http.Error(w, "missing jobId", http.StatusBadRequest)
+			return
+		}
+		s.handleJobEvents(w, r, jobID)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jobID := rest
+	if jobID == "" || strings.Contains(jobID, "/") {
+		http.Error(w, "missing jobId", http.StatusBadRequest)
+		return
+	}
+
+	s.cancelJob(w, jobID)
+}
+
+// handleJobEvents serves GET /jobs/{jobID}/events, upgrading the connection
+// to text/event-stream and emitting the job's phase/progress updates until
+// it reaches a terminal state or the client disconnects.
+func (s *server) handleJobEvents(w http.ResponseWriter, r *http.Request, jobID string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe, err := s.machine.SubscribeJobEvents(jobID)
+	if err != nil {
+		if errors.Is(err, ErrJobNotFound) {
+			http.Error(w, "job not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				s.logger.Printf("jobs events: marshal job %s: %v", jobID, err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+			flusher.Flush()
+			if ev.Type == "done" {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleSchedule serves POST /schedule, registering a one-shot (runAt) or
+// recurring (cron) brew request.
+func (s *server) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Product     Product    `json:"product"`
+		Priority    int        `json:"priority,omitempty"`
+		CallbackURL string     `json:"callbackUrl,omitempty"`
+		RunAt       *time.Time `json:"runAt,omitempty"`
+		Cron        string     `json:"cron,omitempty"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	sch, err := s.scheduler.Add(req.Product, req.Priority, req.CallbackURL, req.RunAt, req.Cron)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnsupportedProduct):
+			http.Error(w, "unsupported product", http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, sch)
+}
+
+// handleSchedules serves GET /schedules, listing every registered schedule.
+func (s *server) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.scheduler.List())
+}
+
+// handleScheduleByID serves DELETE /schedules/{id}.
+func (s *server) handleScheduleByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/schedules/")
+	if id == "" || strings.Contains(id, "/") {
+		http.Error(w, "missing schedule id", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.scheduler.Delete(id); err != nil {
+		switch {
+		case errors.Is(err, ErrJobNotFound):
+			http.Error(w, "schedule not found", http.StatusNotFound)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) cancelJob(w http.ResponseWriter, jobID string) {
+	if err := s.machine.CancelJob(jobID); err != nil {
+		switch {
+		case errors.Is(err, ErrJobNotFound):
+			http.Error(w, "job not found", http.StatusNotFound)
+		case errors.Is(err, ErrJobNotPending):
+			http.Error(w, "job is not pending", http.StatusConflict)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.machine.Queue())
+}
+
 func (s *server) handleRetrieveJob(w http.ResponseWriter, r *http.Request) {
 	jobID := r.URL.Query().Get("jobId")
 	if jobID == "" {
@@ -108,6 +313,18 @@ func (s *server) handleRetrieveJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if wait := r.URL.Query().Get("wait"); wait != "" {
+		d, err := time.ParseDuration(wait)
+		if err != nil {
+			http.Error(w, "invalid wait duration", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+		_, _ = s.machine.WaitReady(ctx, jobID)
+	}
+
 	job, err := s.machine.RetrieveJob(jobID)
 	if err != nil {
 		switch {
@@ -117,6 +334,8 @@ func (s *server) handleRetrieveJob(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "job not ready", http.StatusServiceUnavailable)
 		case errors.Is(err, ErrJobAlreadyRetrieved):
 			http.Error(w, "job already retrieved", http.StatusGone)
+		case errors.Is(err, ErrJobExpired):
+			http.Error(w, "job expired before retrieval", http.StatusGone)
 		default:
 			http.Error(w, "internal error", http.StatusInternalServerError)
 		}
@@ -130,10 +349,82 @@ func (s *server) handleHistory(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, s.machine.History())
 }
 
+// sseEventNames maps the internal storeEventType strings emitted by Machine
+// to the job_* event names sent over /events.
+var sseEventNames = map[string]string{
+	string(eventStarted):   "job_started",
+	string(eventReady):     "job_ready",
+	string(eventRetrieved): "job_retrieved",
+}
+
+// handleEvents streams job_started, job_ready, and job_retrieved
+// notifications as Server-Sent Events for as long as the client stays
+// connected, giving clients a push alternative to polling /status.
+func (s *server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events, unsubscribe := s.machine.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			name, known := sseEventNames[ev.Type]
+			if !known {
+				continue
+			}
+
+			data, err := json.Marshal(ev.Job)
+			if err != nil {
+				s.logger.Printf("events: marshal job %s: %v", ev.Job.JobID, err)
+				continue
+			}
+
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleHooksStatus serves the most recent webhook delivery attempts, for
+// operators diagnosing a misbehaving callback endpoint.
+func (s *server) handleHooksStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.machine.RecentHookDeliveries())
+}
+
+// handleAdminReset serves POST /admin/reset, cancelling every pending or
+// brewing job and clearing the queue, for operators recovering a stuck
+// machine without restarting the process.
+func (s *server) handleAdminReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.machine.Reset()
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (s *server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
-	state := s.machine.Status()
-	_, _ = w.Write([]byte("coffee_machine_status " + strconv.Itoa(int(state)) + "\n"))
+	if err := s.machine.WriteMetrics(w); err != nil {
+		http.Error(w, "render metrics: "+err.Error(), http.StatusInternalServerError)
+	}
 }
 
 func (s *server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
@@ -170,10 +461,10 @@ func stateString(state MachineState) string {
 	}
 }
 
-func start(ctx context.Context, port string, machine *Machine, logger *log.Logger) error {
+func start(ctx context.Context, port string, machine *Machine, scheduler *Scheduler, jwtKey []byte, logger *log.Logger) error {
 	srv := &http.Server{
 		Addr:    ":" + port,
-		Handler: newServer(machine, logger),
+		Handler: newServer(machine, scheduler, logger, jwtKey),
 	}
 
 	go func() {
@@ -198,12 +489,62 @@ func main() {
 	}
 
 	logger := log.New(os.Stdout, "", log.LstdFlags)
-	machine := NewMachine()
+	machine := newMachineFromEnv(logger)
+	scheduler := newSchedulerFromEnv(machine, logger)
+	jwtKey := jwtKeyFromEnv(logger)
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	if err := start(ctx, port, machine, logger); err != nil {
+	if natsURL := os.Getenv("COFFEEPOT_NATS_URL"); natsURL != "" {
+		startBrokerBridge(ctx, natsURL, machine, logger)
+	}
+
+	if err := start(ctx, port, machine, scheduler, jwtKey, logger); err != nil {
 		logger.Fatalf("server error: %v", err)
 	}
 }
+
+// newMachineFromEnv builds a Machine backed by a FileStore when
+// COFFEEPOT_STATE_DIR is set, falling back to the in-memory default
+// otherwise.
+func newMachineFromEnv(logger *log.Logger) *Machine {
+	var machine *Machine
+
+	dir := os.Getenv("COFFEEPOT_STATE_DIR")
+	if dir == "" {
+		machine = NewMachine()
+	} else {
+		store, err := NewFileStore(dir)
+		if err != nil {
+			logger.Fatalf("open job store: %v", err)
+		}
+		machine = NewMachineWithStore(defaultHeads, store)
+	}
+
+	if cap := os.Getenv("COFFEEPOT_QUEUE_CAP"); cap != "" {
+		n, err := strconv.Atoi(cap)
+		if err != nil {
+			logger.Fatalf("invalid COFFEEPOT_QUEUE_CAP %q: %v", cap, err)
+		}
+		machine.SetQueueCap(n)
+	}
+
+	return machine
+}
+
+// newSchedulerFromEnv builds a Scheduler for machine, persisting schedules
+// under COFFEEPOT_STATE_DIR when set so they survive restarts, matching
+// newMachineFromEnv's storage convention.
+func newSchedulerFromEnv(machine *Machine, logger *log.Logger) *Scheduler {
+	path := ""
+	if dir := os.Getenv("COFFEEPOT_STATE_DIR"); dir != "" {
+		path = filepath.Join(dir, "schedules.json")
+	}
+
+	scheduler, err := NewScheduler(machine, path)
+	if err != nil {
+		logger.Fatalf("open scheduler: %v", err)
+	}
+	return scheduler
+}
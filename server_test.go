@@ -1,30 +1,49 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 )
 
+// testServer builds a server wired to an in-memory (non-persisted)
+// scheduler with auth disabled, for tests that don't exercise scheduling or
+// auth directly.
+func testServer(m *Machine) http.Handler {
+	return testServerWithAuth(m, nil)
+}
+
+// testServerWithAuth builds a server wired to an in-memory (non-persisted)
+// scheduler, enabling JWT auth when key is non-empty.
+func testServerWithAuth(m *Machine, key []byte) http.Handler {
+	scheduler, err := NewScheduler(m, "")
+	if err != nil {
+		panic(err)
+	}
+	return newServer(m, scheduler, log.New(io.Discard, "", 0), key)
+}
+
 func TestHandleRetrieveJobUsesJobIDParameter(t *testing.T) {
 	m := NewMachine()
 	m.brewTimeFn = func() time.Duration { return time.Millisecond }
 
-	job, err := m.StartJob(ProductEspresso, "")
+	job, err := m.StartJob(ProductEspresso, "", 0, "")
 	if err != nil {
 		t.Fatalf("start job: %v", err)
 	}
 
 	time.Sleep(2 * time.Millisecond)
 
-	handler := newServer(m, log.New(io.Discard, "", 0))
+	handler := testServer(m)
 
-	req := httptest.NewRequest(http.MethodGet, "/retrieve-job?jobID="+url.QueryEscape(job.JobID), nil)
+	req := httptest.NewRequest(http.MethodGet, "/retrieve-job?jobId="+url.QueryEscape(job.JobID), nil)
 	rec := httptest.NewRecorder()
 
 	handler.ServeHTTP(rec, req)
@@ -43,9 +62,9 @@ func TestHandleRetrieveJobUsesJobIDParameter(t *testing.T) {
 }
 
 func TestHandleRetrieveJobMissingJobID(t *testing.T) {
-	handler := newServer(NewMachine(), log.New(io.Discard, "", 0))
+	handler := testServer(NewMachine())
 
-	req := httptest.NewRequest(http.MethodGet, "/retrieve-job?jobId=legacy", nil)
+	req := httptest.NewRequest(http.MethodGet, "/retrieve-job", nil)
 	rec := httptest.NewRecorder()
 
 	handler.ServeHTTP(rec, req)
@@ -53,7 +72,225 @@ func TestHandleRetrieveJobMissingJobID(t *testing.T) {
 	if rec.Code != http.StatusBadRequest {
 		t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusBadRequest)
 	}
-	if body := rec.Body.String(); body != "missing jobID\n" {
+	if body := rec.Body.String(); body != "missing jobId\n" {
 		t.Fatalf("unexpected body: %q", body)
 	}
 }
+
+func TestHandleQueueReturnsPendingJobs(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 50 * time.Millisecond }
+
+	if _, err := m.StartJob(ProductCoffee, "brewing", 0, ""); err != nil {
+		t.Fatalf("start brewing job: %v", err)
+	}
+	if _, err := m.StartJob(ProductCoffee, "queued", 0, ""); err != nil {
+		t.Fatalf("start queued job: %v", err)
+	}
+
+	handler := testServer(m)
+
+	req := httptest.NewRequest(http.MethodGet, "/queue", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var queue []Job
+	if err := json.NewDecoder(rec.Body).Decode(&queue); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(queue) != 1 || queue[0].JobID != "queued" {
+		t.Fatalf("expected queued job in response, got %+v", queue)
+	}
+}
+
+func TestHandleCancelJobRemovesPendingJob(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 50 * time.Millisecond }
+
+	if _, err := m.StartJob(ProductCoffee, "brewing", 0, ""); err != nil {
+		t.Fatalf("start brewing job: %v", err)
+	}
+	if _, err := m.StartJob(ProductCoffee, "queued", 0, ""); err != nil {
+		t.Fatalf("start queued job: %v", err)
+	}
+
+	handler := testServer(m)
+
+	req := httptest.NewRequest(http.MethodDelete, "/cancel-job?jobId=queued", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if len(m.Queue()) != 0 {
+		t.Fatalf("expected queue to be empty after cancellation")
+	}
+}
+
+func TestHandleJobByIDCancelsJob(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 50 * time.Millisecond }
+
+	if _, err := m.StartJob(ProductCoffee, "brewing", 0, ""); err != nil {
+		t.Fatalf("start brewing job: %v", err)
+	}
+	if _, err := m.StartJob(ProductCoffee, "queued", 0, ""); err != nil {
+		t.Fatalf("start queued job: %v", err)
+	}
+
+	handler := testServer(m)
+
+	req := httptest.NewRequest(http.MethodDelete, "/jobs/queued", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if len(m.Queue()) != 0 {
+		t.Fatalf("expected queue to be empty after cancellation")
+	}
+}
+
+func TestHandleStartJobRejectsWhenQueueFullWithRetryAfter(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 50 * time.Millisecond }
+	m.SetQueueCap(1)
+
+	if _, err := m.StartJob(ProductCoffee, "brewing", 0, ""); err != nil {
+		t.Fatalf("start brewing job: %v", err)
+	}
+	if _, err := m.StartJob(ProductCoffee, "queued", 0, ""); err != nil {
+		t.Fatalf("start queued job: %v", err)
+	}
+
+	handler := testServer(m)
+
+	body := strings.NewReader(`{"product":"COFFEE"}`)
+	req := httptest.NewRequest(http.MethodPost, "/start-job", body)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected a Retry-After header")
+	}
+}
+
+func TestHandleRetrieveJobWaitsForReadiness(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 20 * time.Millisecond }
+
+	job, err := m.StartJob(ProductCoffee, "", 0, "")
+	if err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+
+	handler := testServer(m)
+
+	req := httptest.NewRequest(http.MethodGet, "/retrieve-job?jobId="+url.QueryEscape(job.JobID)+"&wait=1s", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestHandleEventsStreamsJobLifecycle(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 10 * time.Millisecond }
+
+	handler := testServer(m)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(rec, req)
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond) // let the handler subscribe before the job starts
+	if _, err := m.StartJob(ProductCoffee, "", 0, ""); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+
+	<-done
+
+	body := rec.Body.String()
+	if !strings.Contains(body, "event: job_started") {
+		t.Fatalf("expected job_started event, got body:\n%s", body)
+	}
+	if !strings.Contains(body, "event: job_ready") {
+		t.Fatalf("expected job_ready event, got body:\n%s", body)
+	}
+}
+
+func TestHandleHooksStatusReturnsDeliveries(t *testing.T) {
+	m := NewMachine()
+	handler := testServer(m)
+
+	req := httptest.NewRequest(http.MethodGet, "/hooks/status", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var deliveries []HookDelivery
+	if err := json.NewDecoder(rec.Body).Decode(&deliveries); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(deliveries) != 0 {
+		t.Fatalf("expected no deliveries yet, got %+v", deliveries)
+	}
+}
+
+func TestHandleJobEventsStreamsPhasesUntilDone(t *testing.T) {
+	m := NewMachine()
+	m.brewTimeFn = func() time.Duration { return 10 * time.Millisecond }
+
+	job, err := m.StartJob(ProductCoffee, "", 0, "")
+	if err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+
+	handler := testServer(m)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs/"+job.JobID+"/events", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	body := rec.Body.String()
+	if !strings.Contains(body, "event: done") {
+		t.Fatalf("expected a terminal done event, got body:\n%s", body)
+	}
+}
+
+func TestHandleJobEventsUnknownJobReturnsNotFound(t *testing.T) {
+	handler := testServer(NewMachine())
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs/missing/events", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
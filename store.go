@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// storeEventType names a job state transition recorded to a JobStore.
+type storeEventType string
+
+const (
+	eventStarted   storeEventType = "started"
+	eventBrewing   storeEventType = "brewing"
+	eventReady     storeEventType = "ready"
+	eventRetrieved storeEventType = "retrieved"
+	eventCancelled storeEventType = "cancelled"
+	eventExpired   storeEventType = "expired"
+)
+
+// StoreEvent is a single newline-delimited WAL record.
+type StoreEvent struct {
+	Type storeEventType `json:"type"`
+	Job  Job            `json:"job"`
+	At   time.Time      `json:"at"`
+}
+
+type storeSnapshot struct {
+	Jobs    map[string]Job `json:"jobs"`
+	History []string       `json:"history"`
+	At      time.Time      `json:"at"`
+}
+
+// JobStore persists job state transitions so a Machine can recover its
+// in-memory state across restarts.
+type JobStore interface {
+	Append(event StoreEvent) error
+	Snapshot(jobs map[string]Job, history []string) error
+	Recover() (jobs map[string]Job, history []string, err error)
+	Close() error
+}
+
+// MemoryStore is the default, no-op JobStore: nothing survives a restart.
+type MemoryStore struct{}
+
+// NewMemoryStore returns a JobStore that keeps no durable record.
+func NewMemoryStore() *MemoryStore { return &MemoryStore{} }
+
+func (*MemoryStore) Append(StoreEvent) error { return nil }
+
+func (*MemoryStore) Snapshot(map[string]Job, []string) error { return nil }
+
+func (*MemoryStore) Recover() (map[string]Job, []string, error) { return nil, nil, nil }
+
+func (*MemoryStore) Close() error { return nil }
+
+// FileStore is a WAL-backed JobStore: every transition is appended as a
+// newline-delimited JSON record, and Snapshot periodically compacts the WAL
+// into a single snapshot file.
+type FileStore struct {
+	mu       sync.Mutex
+	wal      *os.File
+	walPath  string
+	snapPath string
+}
+
+// NewFileStore opens (creating if necessary) a WAL file under dir.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create state dir: %w", err)
+	}
+
+	walPath := filepath.Join(dir, "wal.log")
+	f, err := os.OpenFile(walPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open wal: %w", err)
+	}
+
+	return &FileStore{
+		wal:      f,
+		walPath:  walPath,
+		snapPath: filepath.Join(dir, "snapshot.json"),
+	}, nil
+}
+
+// Append writes event to the WAL.
+func (f *FileStore) Append(event StoreEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return json.NewEncoder(f.wal).Encode(event)
+}
+
+// Snapshot writes the full job set to disk and truncates the WAL, since the
+// snapshot now captures every event recorded so far.
+func (f *FileStore) Snapshot(jobs map[string]Job, history []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := json.Marshal(storeSnapshot{Jobs: jobs, History: history, At: time.Now()})
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	tmp := f.snapPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, f.snapPath); err != nil {
+		return fmt.Errorf("rename snapshot: %w", err)
+	}
+
+	if err := f.wal.Truncate(0); err != nil {
+		return fmt.Errorf("truncate wal: %w", err)
+	}
+	if _, err := f.wal.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("rewind wal: %w", err)
+	}
+
+	return nil
+}
+
+// Recover reconstructs job state from the latest snapshot plus any WAL
+// entries appended after it.
+func (f *FileStore) Recover() (map[string]Job, []string, error) {
+	jobs := make(map[string]Job)
+	var history []string
+
+	if data, err := os.ReadFile(f.snapPath); err == nil {
+		var snap storeSnapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return nil, nil, fmt.Errorf("decode snapshot: %w", err)
+		}
+		jobs = snap.Jobs
+		history = snap.History
+	} else if !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("read snapshot: %w", err)
+	}
+
+	data, err := os.ReadFile(f.walPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("read wal: %w", err)
+	}
+
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var event StoreEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			// A partially-written final record can happen if the process
+			// died mid-append; skip it rather than fail recovery.
+			continue
+		}
+
+		if _, seen := jobs[event.Job.JobID]; !seen && event.Type == eventStarted {
+			history = append(history, event.Job.JobID)
+		}
+		jobs[event.Job.JobID] = event.Job
+	}
+
+	return jobs, history, nil
+}
+
+// Close releases the underlying WAL file handle.
+func (f *FileStore) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.wal.Close()
+}
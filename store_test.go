@@ -0,0 +1,156 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileStoreRecoversBrewingJob(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+
+	m := NewMachineWithStore(1, store)
+	m.brewTimeFn = func() time.Duration { return time.Hour }
+
+	job, err := m.StartJob(ProductCoffee, "job-1", 0, "")
+	if err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	if job.State != JobBrewing {
+		t.Fatalf("expected job to be brewing, got %v", job.State)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("close store: %v", err)
+	}
+
+	reopened, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("reopen file store: %v", err)
+	}
+	defer reopened.Close()
+
+	recovered := NewMachineWithStore(1, reopened)
+
+	history := recovered.History()
+	if len(history) != 1 || history[0].JobID != "job-1" {
+		t.Fatalf("expected recovered history to contain job-1, got %+v", history)
+	}
+	if history[0].State != JobBrewing {
+		t.Fatalf("expected recovered job to still be brewing, got %v", history[0].State)
+	}
+}
+
+func TestFileStoreRecoversReadyJob(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+
+	m := NewMachineWithStore(1, store)
+	m.brewTimeFn = func() time.Duration { return time.Millisecond }
+
+	job, err := m.StartJob(ProductCoffee, "job-1", 0, "")
+	if err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := store.Close(); err != nil {
+		t.Fatalf("close store: %v", err)
+	}
+
+	reopened, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("reopen file store: %v", err)
+	}
+	defer reopened.Close()
+
+	recovered := NewMachineWithStore(1, reopened)
+
+	got, err := recovered.RetrieveJob(job.JobID)
+	if err != nil {
+		t.Fatalf("retrieve recovered job: %v", err)
+	}
+	if got.JobID != job.JobID {
+		t.Fatalf("expected recovered job %s, got %s", job.JobID, got.JobID)
+	}
+}
+
+func TestFileStoreSnapshotTruncatesWAL(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Append(StoreEvent{Type: eventStarted, Job: Job{JobID: "job-1"}, At: time.Now()}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := store.Snapshot(map[string]Job{"job-1": {JobID: "job-1", State: JobPending}}, []string{"job-1"}); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	jobs, history, err := store.Recover()
+	if err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	if len(jobs) != 1 || jobs["job-1"].State != JobPending {
+		t.Fatalf("expected snapshot to be recovered, got %+v", jobs)
+	}
+	if len(history) != 1 || history[0] != "job-1" {
+		t.Fatalf("expected history to be recovered, got %+v", history)
+	}
+}
+
+// TestSnapshotOnceDoesNotLoseConcurrentAppends guards against a race where
+// snapshotOnce copies jobs/history, releases m.mu, and only then calls
+// store.Snapshot: a StartJob landing in that window would append to the WAL
+// and then have it truncated out from under it by the snapshot, losing the
+// job for good. snapshotOnce must hold m.mu across both the copy and the
+// Snapshot call so no Append can land in between.
+func TestSnapshotOnceDoesNotLoseConcurrentAppends(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+	defer store.Close()
+
+	m := NewMachineWithStore(4, store)
+	m.brewTimeFn = func() time.Duration { return time.Hour }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			jobID := "job-" + string(rune('a'+i))
+			if _, err := m.StartJob(ProductCoffee, jobID, 0, ""); err != nil {
+				t.Errorf("start job %s: %v", jobID, err)
+			}
+		}(i)
+	}
+
+	for i := 0; i < 20; i++ {
+		m.snapshotOnce()
+	}
+	wg.Wait()
+	m.snapshotOnce()
+
+	jobs, _, err := store.Recover()
+	if err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	if len(jobs) != 20 {
+		t.Fatalf("expected all 20 concurrently started jobs to survive snapshotting, got %d: %+v", len(jobs), jobs)
+	}
+}